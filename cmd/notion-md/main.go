@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/wiremind/markdown-to-notionapi/internal/run"
@@ -29,8 +30,34 @@ func main() {
 	flag.BoolVar(&config.Replace, "replace", false, "Replace existing page content")
 	flag.BoolVar(&config.Create, "create", false, "Create a new page")
 	flag.StringVar(&config.ImageBaseURL, "image-base-url", "", "Base URL for relative image paths")
+	flag.BoolVar(&config.NoCaptions, "no-image-captions", false, "Don't use image titles or alt text as captions")
+	flag.StringVar(&config.Dialect, "dialect", "", "Markdown dialect to parse: gfm, obsidian or mkdocs (default: plain Markdown)")
+	flag.StringVar(&config.TagsProperty, "tags-property", "", "Multi-select property to set the #tags of an Obsidian document in")
+	flag.BoolVar(&config.DefinitionToggles, "definition-toggles", false, "Convert definition list terms to toggles instead of bold paragraphs")
+	flag.BoolVar(&config.DetectLanguage, "detect-language", false, "Guess the language of code blocks that have none (guesses are shown with -v)")
+	flag.StringVar(&config.Typography, "typography", "", "Use typographic quotes, dashes and ellipses for a locale: en or fr")
+	flag.Var((*stringList)(&config.TypographySubs), "typography-sub", "Typographic substitution as name=text, e.g. em_dash=– (repeatable, implies --typography)")
+	flag.StringVar(&config.ImageUpload, "image-upload", "notion", "Where local images are hosted: notion, s3, dir or none")
+	flag.StringVar(&config.ImageDir, "image-dir", "", "Directory to copy local images to (with --image-upload dir)")
+	flag.StringVar(&config.ImageDirURL, "image-dir-url", "", "Public base URL of --image-dir (with --image-upload dir)")
+	flag.StringVar(&config.S3Endpoint, "s3-endpoint", "", "S3-compatible endpoint URL (with --image-upload s3)")
+	flag.StringVar(&config.S3Region, "s3-region", "us-east-1", "S3 region")
+	flag.StringVar(&config.S3Bucket, "s3-bucket", "", "S3 bucket for images")
+	flag.StringVar(&config.S3Prefix, "s3-prefix", "", "Key prefix for images in the S3 bucket")
+	flag.StringVar(&config.S3PublicURL, "s3-public-url", "", "Public base URL for S3 objects (default: the bucket URL)")
+	flag.StringVar(&config.LinkBaseURL, "link-base-url", "", "Base URL for relative links to unpublished files")
+	flag.StringVar(&config.LinkMapFile, "link-map", "", "JSON file mapping relative Markdown paths to Notion page IDs or URLs")
+	flag.StringVar(&config.Icon, "icon", "", "Page icon as an emoji or shortcode such as :rocket:")
+	flag.BoolVar(&config.LinkFootnotes, "link-footnotes", false, "Link footnote references to their notes after upload")
+	flag.StringVar(&config.Profile, "profile", "", "Destination profiles selecting content marked with profile=... (comma-separated)")
+	flag.BoolVar(&config.Template, "template", false, "Expand the Markdown as a Go template before conversion")
+	flag.Var((*stringList)(&config.Vars), "var", "Template variable as key=value (repeatable, implies --template)")
+	flag.StringVar(&config.VarsFile, "vars-file", "", "YAML or JSON file of template variables (implies --template)")
 	flag.BoolVar(&config.DryRun, "dry-run", false, "Print JSON that would be sent, don't call API")
 	flag.StringVar(&config.OutputFile, "output-file", "", "File to write dry-run output to (default: stdout)")
+	flag.BoolVar(&config.Strict, "strict", false, "Fail before calling the API if any content would be lost in conversion")
+	flag.BoolVar(&config.Stream, "stream", false, "Convert and upload large documents in segments as they are read")
+	flag.StringVar(&config.DiagnosticsFile, "diagnostics-file", "", "File to write conversion diagnostics to as JSON")
 	flag.StringVar(&config.NotionVersion, "notion-version", defaultNotionVersion, "Notion API version")
 	flag.BoolVar(&config.Verbose, "v", false, "Verbose output")
 	flag.BoolVar(&config.Verbose, "verbose", false, "Verbose output")
@@ -47,10 +74,15 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s --create --parent-id xyz789 --title \"My Document\" --md notes.md\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --dry-run --md document.md\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --dry-run --md document.md --output-file output.json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --page-id abc123 --md release.md --var version=1.4.0\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --stream --page-id abc123 --md large.md\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
-		fmt.Fprintf(os.Stderr, "  NOTION_TOKEN    Notion integration token (required unless --dry-run is used)\n")
+		fmt.Fprintf(os.Stderr, "  NOTION_TOKEN           Notion integration token (required unless --dry-run is used)\n")
+		fmt.Fprintf(os.Stderr, "  AWS_ACCESS_KEY_ID      S3 access key (with --image-upload s3)\n")
+		fmt.Fprintf(os.Stderr, "  AWS_SECRET_ACCESS_KEY  S3 secret key (with --image-upload s3)\n")
+		fmt.Fprintf(os.Stderr, "  AWS_SESSION_TOKEN      S3 session token of temporary credentials (optional)\n")
 		fmt.Fprintf(os.Stderr, "\nSupported Markdown:\n")
 		fmt.Fprintf(os.Stderr, "  - Headings (# ## ###)\n")
 		fmt.Fprintf(os.Stderr, "  - Paragraphs with **bold**, *italic*, `code`, ~~strikethrough~~, [links](url)\n")
@@ -58,7 +90,9 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  - Block quotes\n")
 		fmt.Fprintf(os.Stderr, "  - Fenced code blocks ```lang\n")
 		fmt.Fprintf(os.Stderr, "  - Horizontal rules ---\n")
-		fmt.Fprintf(os.Stderr, "  - Images (external URLs only)\n")
+		fmt.Fprintf(os.Stderr, "  - Images (external URLs, local files and data URIs)\n")
+		fmt.Fprintf(os.Stderr, "  - Footnotes [^1] (collected into a notes section)\n")
+		fmt.Fprintf(os.Stderr, "  - Emoji shortcodes :rocket: (expanded to Unicode)\n")
 	}
 
 	flag.Parse()
@@ -73,6 +107,11 @@ func main() {
 		config.MarkdownFile = flag.Arg(0)
 	}
 
+	// S3 credentials come from the usual AWS environment variables
+	config.S3AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	config.S3SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	config.S3SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+
 	// Get Notion token from environment
 	notionToken := os.Getenv("NOTION_TOKEN")
 	if notionToken == "" && !config.DryRun {
@@ -99,3 +138,15 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
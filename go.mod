@@ -2,4 +2,9 @@ module github.com/wiremind/markdown-to-notionapi
 
 go 1.25
 
-require github.com/yuin/goldmark v1.7.4
+require (
+	github.com/rivo/uniseg v0.4.7
+	github.com/yuin/goldmark v1.7.4
+	github.com/yuin/goldmark-emoji v1.0.5
+	gopkg.in/yaml.v3 v3.0.1
+)
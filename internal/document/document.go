@@ -0,0 +1,155 @@
+// internal/document/document.go
+
+// Package document is the intermediate model of converted documents: a
+// tree of blocks holding inline spans with marks. Front ends such as the
+// Markdown converter build documents, transforms rewrite them and back ends
+// such as the Notion renderer serialise them.
+package document
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Document is a converted document
+type Document struct {
+	Blocks []*Block
+	// Tags are the tags of the page, such as Obsidian #tags, in order of
+	// first appearance
+	Tags []string
+}
+
+// Kind identifies the type of a block
+type Kind string
+
+// Block kinds
+const (
+	KindParagraph    Kind = "paragraph"
+	KindHeading      Kind = "heading"
+	KindBulletedItem Kind = "bulleted_item"
+	KindNumberedItem Kind = "numbered_item"
+	KindQuote        Kind = "quote"
+	KindToggle       Kind = "toggle"
+	KindCallout      Kind = "callout"
+	KindToDo         Kind = "to_do"
+	KindCode         Kind = "code"
+	KindDivider      Kind = "divider"
+	KindImage        Kind = "image"
+	KindTable        Kind = "table"
+	KindTableRow     Kind = "table_row"
+	KindRaw          Kind = "raw"
+)
+
+// Block is a block of content. Which fields are used depends on Kind.
+type Block struct {
+	Kind Kind
+	// Spans is the text of paragraphs, headings, list items, quotes, toggles,
+	// callouts and code
+	Spans []Span
+	// Children are the nested blocks of list items, paragraphs, toggles and
+	// callouts, and the rows of tables
+	Children []*Block
+
+	// Level is the level of headings, from 1
+	Level int
+	// Language is the language of code blocks
+	Language string
+	// Image is the source and caption of images
+	Image *Image
+	// Table describes the shape of tables
+	Table *Table
+	// Cells are the cells of table rows
+	Cells [][]Span
+	// Raw is a block written in the output format, passed through verbatim
+	Raw []byte
+	// Icon is the emoji of callouts and Color their background, a Notion
+	// color such as "blue_background"
+	Icon  string
+	Color string
+	// Checked tells whether a to-do item is done
+	Checked bool
+
+	// Anchor names the block as the target of intra-document "#anchor" links
+	Anchor string
+	// Source is the range of source lines the block was converted from
+	Source SourceRange
+}
+
+// Text returns the plain text of the block's own spans
+func (b *Block) Text() string {
+	return SpansText(b.Spans)
+}
+
+// Image is the source and caption of an image block
+type Image struct {
+	// URL is the address of a hosted image
+	URL string
+	// File is the content of a local or embedded image still to be uploaded
+	File *File
+	// Caption is shown under the image
+	Caption []Span
+}
+
+// File is the content of a local file
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Table describes the shape of a table; its rows are the block's children
+type Table struct {
+	Width           int
+	HasColumnHeader bool
+	HasRowHeader    bool
+}
+
+// Span is a run of text sharing the same marks and link
+type Span struct {
+	Text  string
+	Marks Marks
+	// Href is the link target, empty when the span is not a link
+	Href string
+}
+
+// Marks are the inline formats applied to a span
+type Marks struct {
+	Bold          bool
+	Italic        bool
+	Strikethrough bool
+	Underline     bool
+	Code          bool
+}
+
+// Text returns a span with plain text
+func Text(text string) Span {
+	return Span{Text: text}
+}
+
+// SpansText returns the plain text of spans
+func SpansText(spans []Span) string {
+	var b strings.Builder
+	for _, span := range spans {
+		b.WriteString(span.Text)
+	}
+	return b.String()
+}
+
+// SourceRange is an inclusive range of 1-based source lines
+type SourceRange struct {
+	StartLine int
+	EndLine   int
+}
+
+// IsZero reports whether the range is unknown
+func (r SourceRange) IsZero() bool {
+	return r.StartLine == 0
+}
+
+// String formats the range as "line N" or "lines N-M"
+func (r SourceRange) String() string {
+	if r.EndLine <= r.StartLine {
+		return fmt.Sprintf("line %d", r.StartLine)
+	}
+	return fmt.Sprintf("lines %d-%d", r.StartLine, r.EndLine)
+}
@@ -0,0 +1,93 @@
+package document
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func heading(level int, text string) *Block {
+	return &Block{Kind: KindHeading, Level: level, Spans: []Span{Text(text)}}
+}
+
+func paragraph(text string) *Block {
+	return &Block{Kind: KindParagraph, Spans: []Span{Text(text)}}
+}
+
+func TestRewrite(t *testing.T) {
+	item := &Block{Kind: KindBulletedItem, Spans: []Span{Text("item")}, Children: []*Block{
+		paragraph("drop"),
+		paragraph("keep"),
+	}}
+	blocks := []*Block{paragraph("drop"), item}
+
+	var visited []string
+	got, err := Rewrite(blocks, func(b *Block) ([]*Block, error) {
+		visited = append(visited, b.Text())
+		if b.Text() == "drop" {
+			return nil, nil
+		}
+		return []*Block{b}, nil
+	})
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+
+	if len(got) != 1 || got[0] != item {
+		t.Fatalf("got %d blocks, want only the item", len(got))
+	}
+	if len(item.Children) != 1 || item.Children[0].Text() != "keep" {
+		t.Errorf("item children = %v, want the dropped child removed", item.Children)
+	}
+	if want := []string{"drop", "drop", "keep", "item"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited %v, want children before their parent: %v", visited, want)
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	custom := heading(2, "Setup")
+	custom.Anchor = "custom"
+	doc := &Document{Blocks: []*Block{
+		heading(1, "Getting Started!"),
+		heading(2, "Setup"),
+		custom,
+		{Kind: KindBulletedItem, Children: []*Block{heading(3, "Setup")}},
+		heading(2, "???"),
+	}}
+
+	if err := HeadingAnchors(doc, nil); err != nil {
+		t.Fatalf("HeadingAnchors() error = %v", err)
+	}
+
+	var anchors []string
+	Walk(doc.Blocks, func(b *Block) error {
+		if b.Kind == KindHeading {
+			anchors = append(anchors, b.Anchor)
+		}
+		return nil
+	})
+	want := []string{"getting-started", "setup", "custom", "setup-1", ""}
+	if !reflect.DeepEqual(anchors, want) {
+		t.Errorf("anchors = %q, want %q", anchors, want)
+	}
+}
+
+func TestPipelineStopsOnError(t *testing.T) {
+	var ran []string
+	step := func(name string, err error) Transform {
+		return func(doc *Document, report ReportFunc) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	err := Pipeline{step("a", nil), step("b", errTest), step("c", nil)}.Apply(&Document{}, nil)
+	if err != errTest {
+		t.Errorf("Apply() error = %v, want %v", err, errTest)
+	}
+	if !reflect.DeepEqual(ran, []string{"a", "b"}) {
+		t.Errorf("ran %v, want the pipeline to stop at the failing transform", ran)
+	}
+}
+
+var errTest = errors.New("transform failed")
@@ -0,0 +1,84 @@
+// internal/document/transform.go
+package document
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Severity tells how much a diagnostic matters
+type Severity string
+
+const (
+	// SeverityInfo marks content that was altered but kept, e.g. a split code block
+	SeverityInfo Severity = "info"
+	// SeverityWarning marks content that did not make it into the output
+	SeverityWarning Severity = "warning"
+)
+
+// ReportFunc records a diagnostic about a block a transform altered or dropped
+type ReportFunc func(b *Block, severity Severity, kind, message string)
+
+// Transform rewrites a document in place
+type Transform func(doc *Document, report ReportFunc) error
+
+// Pipeline is a sequence of transforms applied in order
+type Pipeline []Transform
+
+// Apply runs the transforms of the pipeline on doc
+func (p Pipeline) Apply(doc *Document, report ReportFunc) error {
+	for _, transform := range p {
+		if err := transform(doc, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HeadingAnchors names every heading without an anchor after its text the
+// way GitHub does, adding a counter when the same text appears again
+func HeadingAnchors(doc *Document, report ReportFunc) error {
+	return NewHeadingAnchors()(doc, report)
+}
+
+// NewHeadingAnchors returns a transform naming headings like HeadingAnchors
+// that keeps counting across the documents it is applied to, for documents
+// converted in parts
+func NewHeadingAnchors() Transform {
+	slugs := make(map[string]int)
+	return func(doc *Document, report ReportFunc) error {
+		return Walk(doc.Blocks, func(b *Block) error {
+			if b.Kind != KindHeading || b.Anchor != "" {
+				return nil
+			}
+
+			slug := Slugify(b.Text())
+			if slug == "" {
+				return nil
+			}
+			count := slugs[slug]
+			slugs[slug] = count + 1
+			b.Anchor = slug
+			if count > 0 {
+				b.Anchor = fmt.Sprintf("%s-%d", slug, count)
+			}
+			return nil
+		})
+	}
+}
+
+// Slugify turns heading text into an anchor the way GitHub does: lower case,
+// punctuation dropped and spaces replaced with hyphens
+func Slugify(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
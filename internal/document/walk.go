@@ -0,0 +1,53 @@
+// internal/document/walk.go
+package document
+
+// Walk calls fn for every block of the tree in document order, parents
+// before their children, and stops at the first error
+func Walk(blocks []*Block, fn func(b *Block) error) error {
+	for _, b := range blocks {
+		if err := fn(b); err != nil {
+			return err
+		}
+		if err := Walk(b.Children, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces every block of the tree with the blocks fn returns for
+// it, children first. Returning the block itself keeps it, returning no
+// blocks removes it.
+func Rewrite(blocks []*Block, fn func(b *Block) ([]*Block, error)) ([]*Block, error) {
+	result := make([]*Block, 0, len(blocks))
+	for _, b := range blocks {
+		children, err := Rewrite(b.Children, fn)
+		if err != nil {
+			return nil, err
+		}
+		if b.Children != nil {
+			b.Children = children
+		}
+
+		replacement, err := fn(b)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, replacement...)
+	}
+	return result, nil
+}
+
+// EachSpans calls fn for every list of spans of the block's own content:
+// its text, image caption and table cells
+func (b *Block) EachSpans(fn func(spans *[]Span)) {
+	if b.Spans != nil {
+		fn(&b.Spans)
+	}
+	if b.Image != nil && b.Image.Caption != nil {
+		fn(&b.Image.Caption)
+	}
+	for i := range b.Cells {
+		fn(&b.Cells[i])
+	}
+}
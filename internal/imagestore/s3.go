@@ -0,0 +1,263 @@
+// internal/imagestore/s3.go
+package imagestore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// S3Config configures an S3-compatible object store
+type S3Config struct {
+	// Endpoint is the base URL of the object store, e.g. https://s3.eu-west-1.amazonaws.com
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// SessionToken is set with temporary credentials, such as those of an
+	// assumed role
+	SessionToken string
+	// PublicURL is the base URL objects are served from; defaults to the bucket URL
+	PublicURL string
+	Timeout   time.Duration
+	DryRun    bool
+}
+
+// S3 stores images in an S3-compatible bucket using path-style requests
+// signed with AWS Signature Version 4
+type S3 struct {
+	config     S3Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3 creates an S3 store
+func NewS3(config S3Config) (*S3, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("S3 endpoint and bucket are required")
+	}
+	if _, err := url.ParseRequestURI(config.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", config.Endpoint, err)
+	}
+	// A dry run never signs a request
+	if !config.DryRun && (config.AccessKey == "" || config.SecretKey == "") {
+		return nil, fmt.Errorf("S3 access key and secret key are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &S3{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		now:        time.Now,
+	}, nil
+}
+
+// Upload implements markdown.ImageUploader
+func (s *S3) Upload(ctx context.Context, file notion.FileData) (string, error) {
+	key := ObjectKey(file)
+	if s.config.Prefix != "" {
+		key = strings.Trim(s.config.Prefix, "/") + "/" + key
+	}
+
+	objectURL := joinURL(s.config.Endpoint, awsEscapePath(s.config.Bucket+"/"+key))
+	publicURL := objectURL
+	if s.config.PublicURL != "" {
+		publicURL = joinURL(s.config.PublicURL, awsEscapePath(key))
+	}
+
+	if s.config.DryRun {
+		return publicURL, nil
+	}
+
+	// Keys are content addressed, an existing object already holds this image
+	exists, err := s.exists(ctx, objectURL)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return publicURL, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", objectURL, bytes.NewReader(file.Data))
+	if err != nil {
+		return "", fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", file.ContentType)
+	if err := s.do(req, file.Data); err != nil {
+		return "", fmt.Errorf("failed to upload %s to S3: %w", key, err)
+	}
+
+	return publicURL, nil
+}
+
+// exists reports whether an object is already stored at objectURL
+func (s *S3) exists(ctx context.Context, objectURL string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", objectURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	err = s.do(req, nil)
+	if err == nil {
+		return true, nil
+	}
+	if statusErr, ok := err.(*s3StatusError); ok && statusErr.status == http.StatusNotFound {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check S3 object: %w", err)
+}
+
+// s3StatusError is returned for unsuccessful S3 responses
+type s3StatusError struct {
+	status int
+	body   string
+}
+
+func (e *s3StatusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("S3 error (%d)", e.status)
+	}
+	return fmt.Sprintf("S3 error (%d): %s", e.status, e.body)
+}
+
+// do signs and sends a request, turning error statuses into errors
+func (s *S3) do(req *http.Request, payload []byte) error {
+	sum := sha256.Sum256(payload)
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+	signV4(req, hex.EncodeToString(sum[:]), s.config.AccessKey, s.config.SecretKey, s.config.Region, s.now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 300))
+		return &s3StatusError{status: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	return nil
+}
+
+// signV4 adds AWS Signature Version 4 headers to an S3 request.
+// Every header already set on req is signed, along with the host.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region string, now time.Time) {
+	const service = "s3"
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 requires
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscapePath escapes every segment of a slash-separated path
+func awsEscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsEscape percent-encodes everything but unreserved characters
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '.' || ch == '_' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,99 @@
+// internal/imagestore/store.go
+package imagestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// Noop drops every image, leaving local images out of the page
+type Noop struct{}
+
+// Upload implements markdown.ImageUploader
+func (Noop) Upload(ctx context.Context, file notion.FileData) (string, error) {
+	return "", nil
+}
+
+// Directory copies images into a local directory that is served at a public
+// base URL, such as a GitHub Pages checkout
+type Directory struct {
+	dir     string
+	baseURL string
+	dryRun  bool
+}
+
+// NewDirectory creates a Directory store writing to dir and serving from baseURL.
+// With dryRun set, URLs are computed but nothing is written.
+func NewDirectory(dir, baseURL string, dryRun bool) (*Directory, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("image directory is required")
+	}
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid public base URL %q: %w", baseURL, err)
+	}
+	return &Directory{dir: dir, baseURL: baseURL, dryRun: dryRun}, nil
+}
+
+// Upload implements markdown.ImageUploader
+func (d *Directory) Upload(ctx context.Context, file notion.FileData) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	key := ObjectKey(file)
+	target := filepath.Join(d.dir, filepath.FromSlash(key))
+
+	if !d.dryRun {
+		// Keys are content addressed, an existing file already holds this image
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return "", fmt.Errorf("failed to create image directory: %w", err)
+			}
+			if err := os.WriteFile(target, file.Data, 0644); err != nil {
+				return "", fmt.Errorf("failed to write image: %w", err)
+			}
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check image: %w", err)
+		}
+	}
+
+	return joinURL(d.baseURL, key), nil
+}
+
+// ObjectKey returns the content-addressed name an image is stored under,
+// so the same image is only ever stored once
+func ObjectKey(file notion.FileData) string {
+	sum := sha256.Sum256(file.Data)
+	name := sanitizeName(file.Name)
+	if name == "" {
+		name = "image"
+	}
+	return hex.EncodeToString(sum[:8]) + "/" + name
+}
+
+// sanitizeName keeps file names safe to use in object keys and URLs
+func sanitizeName(name string) string {
+	name = path.Base(filepath.ToSlash(name))
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '.', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, name)
+}
+
+// joinURL appends an object key to a base URL
+func joinURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + key
+}
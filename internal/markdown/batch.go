@@ -0,0 +1,91 @@
+// internal/markdown/batch.go
+package markdown
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// Source is a Markdown document to convert in a batch
+type Source struct {
+	// Path locates the document. Relative links and images are resolved
+	// against its directory, and the file is read when Content is nil.
+	Path string
+	// Content is the Markdown of the document
+	Content []byte
+}
+
+// BatchResult is the outcome of converting one Source
+type BatchResult struct {
+	Path        string
+	Blocks      []notion.Block
+	Diagnostics []Diagnostic
+	Err         error
+}
+
+// ConvertBatch converts sources with a pool of workers and returns their
+// results in the order of sources. With workers <= 0 it uses one worker per
+// CPU. Once ctx is done the sources not yet converted fail with its error.
+func (c *Converter) ConvertBatch(ctx context.Context, sources []Source, workers int) []BatchResult {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(sources) {
+		workers = len(sources)
+	}
+
+	results := make([]BatchResult, len(sources))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results[index] = c.convertSource(ctx, sources[index])
+			}
+		}()
+	}
+
+	for index := range sources {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// convertSource converts a single document of a batch
+func (c *Converter) convertSource(ctx context.Context, src Source) BatchResult {
+	result := BatchResult{Path: src.Path}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	content := src.Content
+	if content == nil {
+		data, err := os.ReadFile(src.Path)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to read %s: %w", src.Path, err)
+			return result
+		}
+		content = data
+	}
+
+	conv := c.begin()
+	conv.ctx = ctx
+	if src.Path != "" {
+		conv.sourceDir = filepath.Dir(src.Path)
+	}
+	result.Blocks, result.Diagnostics, result.Err = conv.convert(content)
+	return result
+}
@@ -0,0 +1,81 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConverter_ConvertBatch(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		data := append(append([]byte{}, pngHeader...), name...)
+		if err := os.WriteFile(filepath.Join(dir, name, "shot.png"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	onDisk := filepath.Join(dir, "b", "doc.md")
+	if err := os.WriteFile(onDisk, []byte("# B\n\n![shot](shot.png)\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var sources []Source
+	for i := 0; i < 20; i++ {
+		sources = append(sources, Source{Content: []byte(fmt.Sprintf("# Document %d\n\n<b>html</b>\n", i))})
+	}
+	sources = append(sources,
+		Source{Path: filepath.Join(dir, "a", "doc.md"), Content: []byte("![shot](shot.png)\n")},
+		Source{Path: onDisk},
+		Source{Path: filepath.Join(dir, "missing.md")},
+	)
+
+	results := NewConverter("", false).ConvertBatch(context.Background(), sources, 4)
+	if len(results) != len(sources) {
+		t.Fatalf("got %d results, want %d", len(results), len(sources))
+	}
+
+	for i := 0; i < 20; i++ {
+		r := results[i]
+		if r.Err != nil {
+			t.Fatalf("result %d error = %v", i, r.Err)
+		}
+		if got := plainText(r.Blocks[0].Heading1.RichText); got != fmt.Sprintf("Document %d", i) {
+			t.Errorf("result %d is %q, results are out of order", i, got)
+		}
+		if len(r.Diagnostics) != 2 || r.Diagnostics[0].Kind != KindHTMLDropped {
+			t.Errorf("result %d diagnostics = %v, want its own diagnostic only", i, r.Diagnostics)
+		}
+	}
+
+	// Images resolve against the directory of each document
+	for i, want := range []string{"a", "b"} {
+		r := results[20+i]
+		if r.Err != nil {
+			t.Fatalf("%s error = %v", r.Path, r.Err)
+		}
+		img := r.Blocks[len(r.Blocks)-1].Image
+		if img == nil || img.Pending == nil || string(img.Pending.Data[len(pngHeader):]) != want {
+			t.Errorf("%s image = %+v, want the image next to the document", r.Path, img)
+		}
+	}
+
+	if err := results[22].Err; err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file error = %v, want not exist", err)
+	}
+}
+
+func TestConverter_ConvertBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := NewConverter("", false).ConvertBatch(ctx, []Source{{Content: []byte("text")}}, 0)
+	if len(results) != 1 || !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("got %+v, want the source cancelled", results)
+	}
+}
@@ -0,0 +1,85 @@
+package markdown
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchmarkDocument generates a document of the given number of sections
+// using most of the supported Markdown
+func benchmarkDocument(sections int) []byte {
+	var b strings.Builder
+	for i := 0; i < sections; i++ {
+		fmt.Fprintf(&b, "## Section %d :rocket:\n\n", i)
+		b.WriteString("Some **bold**, *italic*, `code` and a [link](https://example.com/page) in a paragraph")
+		fmt.Fprintf(&b, " with a footnote[^%d].\n\n", i)
+		b.WriteString("- first item\n  - nested item\n- second item\n\n")
+		b.WriteString("> A quote with ~~strikethrough~~.\n\n")
+		b.WriteString("```go\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n```\n\n")
+		b.WriteString("| Name | Value |\n|------|-------|\n| a | 1 |\n| b | 2 |\n\n")
+		fmt.Fprintf(&b, "[^%d]: Note %d.\n\n", i, i)
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkConvert(b *testing.B) {
+	for _, sections := range []int{10, 100, 1000} {
+		source := benchmarkDocument(sections)
+		b.Run(fmt.Sprintf("sections=%d", sections), func(b *testing.B) {
+			c := NewConverter("", false)
+			b.SetBytes(int64(len(source)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := c.Convert(source); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkConvertLongCode(b *testing.B) {
+	source := []byte("```go\n" + strings.Repeat("fmt.Println(\"a line of code\")\n", 20000) + "```\n")
+	c := NewConverter("", false)
+	b.SetBytes(int64(len(source)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.Convert(source); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertParallel(b *testing.B) {
+	source := benchmarkDocument(100)
+	c := NewConverter("", false)
+	b.SetBytes(int64(len(source)))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, _, err := c.Convert(source); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkConvertBatch(b *testing.B) {
+	sources := make([]Source, 200)
+	for i := range sources {
+		sources[i] = Source{Content: benchmarkDocument(20)}
+	}
+	c := NewConverter("", false)
+	b.SetBytes(int64(len(sources) * len(sources[0].Content)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, r := range c.ConvertBatch(context.Background(), sources, 0) {
+			if r.Err != nil {
+				b.Fatal(r.Err)
+			}
+		}
+	}
+}
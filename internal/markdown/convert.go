@@ -2,140 +2,335 @@
 package markdown
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
 	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+	"github.com/wiremind/markdown-to-notionapi/internal/render"
 	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
 )
 
 // Converter handles Markdown to Notion block conversion
 type Converter struct {
-	imageBaseURL string
-	verbose      bool
+	imageBaseURL  string
+	linkBaseURL   string
+	linkMap       LinkMap
+	sourceDir     string
+	imageUploader ImageUploader
+	verbose       bool
+	linkFootnotes bool
+	noCaptions    bool
+	// detectLanguage guesses the language of unlabeled code blocks
+	detectLanguage bool
+	// definitionToggles converts definition list terms to toggles
+	definitionToggles bool
+	// typography sets the typographic punctuation, nil when disabled
+	typography Typography
+	// dialect adds the syntax of a Markdown flavour to the parser
+	dialect Dialect
+
+	// extensions are added to the Markdown parser; blockHandlers and
+	// inlineHandlers convert the AST nodes, keyed by node kind
+	extensions []goldmark.Extender
+	// parser is built once the options are applied and shared by every
+	// document, as goldmark parsers are safe for concurrent use
+	parser         parser.Parser
+	blockHandlers  map[ast.NodeKind]BlockHandler
+	inlineHandlers map[ast.NodeKind]InlineHandler
+
+	// transforms rewrite the document before it is rendered for Notion
+	transforms []document.Transform
+
+	// Per-document state, set up by Convert on its own copy:
+	// nodes maps blocks to the AST nodes they were converted from,
+	// lineStarts indexes the offsets of source lines, diagnostics collects
+	// everything that was dropped or altered
+	nodes       map[*document.Block]ast.Node
+	lineStarts  []int
+	diagnostics []Diagnostic
+	// ctx is the context of the conversion, passed to the image uploader
+	ctx context.Context
+	// tags collects the page tags of the document in order
+	tags []string
+
+	// State shared by the segments of a streamed document: anchors names
+	// headings, firstLine is the line the segment starts at, footnotes
+	// counts the footnotes of earlier segments and lastFootnote is the
+	// number of the last footnote converted. references resolves link
+	// references across segments; it is nil outside of streams.
+	anchors      document.Transform
+	firstLine    int
+	footnotes    int
+	lastFootnote int
+	references   *segmentReferences
 }
 
-// NewConverter creates a new Markdown converter
-func NewConverter(imageBaseURL string, verbose bool) *Converter {
-	return &Converter{
-		imageBaseURL: imageBaseURL,
-		verbose:      verbose,
+// Option configures optional Converter behaviour
+type Option func(*Converter)
+
+// WithFootnoteLinks makes footnote references link to their note blocks.
+// The links use "#fn-N" anchors that must be resolved after upload.
+func WithFootnoteLinks(enabled bool) Option {
+	return func(c *Converter) {
+		c.linkFootnotes = enabled
+	}
+}
+
+// WithLinkBaseURL sets the base URL that relative links are joined with
+// when they do not point at a mapped document
+func WithLinkBaseURL(baseURL string) Option {
+	return func(c *Converter) {
+		c.linkBaseURL = baseURL
+	}
+}
+
+// WithLinkMap rewrites relative links to mapped documents to their Notion pages
+func WithLinkMap(links LinkMap) Option {
+	return func(c *Converter) {
+		c.linkMap = links
+	}
+}
+
+// WithSourceFile sets the path of the Markdown file being converted, used to
+// resolve relative paths. Without it they are resolved against the working directory.
+func WithSourceFile(path string) Option {
+	return func(c *Converter) {
+		c.sourceDir = filepath.Dir(path)
+	}
+}
+
+// WithImageUploader hosts local and embedded images with u instead of
+// uploading them to Notion. Images with identical content are uploaded once.
+func WithImageUploader(u ImageUploader) Option {
+	return func(c *Converter) {
+		if u == nil {
+			c.imageUploader = nil
+			return
+		}
+		c.imageUploader = &dedupUploader{uploader: u, urls: make(map[string]string)}
 	}
 }
 
-// Convert parses Markdown content and returns Notion blocks
-func (c *Converter) Convert(markdown []byte) ([]notion.Block, error) {
-	md := goldmark.New(
-		goldmark.WithExtensions(extension.Table),
-	)
-	doc := md.Parser().Parse(text.NewReader(markdown))
+// WithImageCaptions controls whether images get a caption from their
+// title or alt text. Captions are enabled by default.
+func WithImageCaptions(enabled bool) Option {
+	return func(c *Converter) {
+		c.noCaptions = !enabled
+	}
+}
 
-	var blocks []notion.Block
-	for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
+// NewConverter creates a new Markdown converter. The converter is safe for
+// concurrent use once created, provided the extensions, handlers and image
+// uploader it is given are.
+func NewConverter(imageBaseURL string, verbose bool, opts ...Option) *Converter {
+	c := &Converter{
+		imageBaseURL:   imageBaseURL,
+		sourceDir:      ".",
+		verbose:        verbose,
+		blockHandlers:  builtinBlockHandlers(),
+		inlineHandlers: builtinInlineHandlers(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	extensions := append(c.dialect.extensions(), c.extensions...)
+	if c.typography != nil {
+		extensions = append(slices.Clip(extensions), c.typography.typographer())
+	}
+	c.parser = newParser(extensions)
+	return c
+}
+
+// newParser builds the Markdown parser with the built-in extensions
+// followed by the extra ones
+func newParser(extra []goldmark.Extender) parser.Parser {
+	extensions := []goldmark.Extender{
+		extension.Table,
+		extension.Footnote,
+		extension.DefinitionList,
+		emoji.New(emoji.WithEmojis(emojis)),
+	}
+	md := goldmark.New(goldmark.WithExtensions(append(extensions, extra...)...))
+	return md.Parser()
+}
+
+// Convert parses Markdown content and returns Notion blocks, along with
+// diagnostics for the content that was dropped or altered on the way
+func (c *Converter) Convert(markdown []byte) ([]notion.Block, []Diagnostic, error) {
+	return c.ConvertContext(context.Background(), markdown)
+}
+
+// ConvertContext converts Markdown content like Convert. Images sent to the
+// image uploader are uploaded with ctx.
+func (c *Converter) ConvertContext(ctx context.Context, markdown []byte) ([]notion.Block, []Diagnostic, error) {
+	// Work on a copy so per-document state never leaks between calls
+	conv := c.begin()
+	conv.ctx = ctx
+	return conv.convert(markdown)
+}
+
+// Page is a converted document with its page metadata
+type Page struct {
+	Blocks []notion.Block
+	// Tags are the page tags found in the document, such as Obsidian #tags
+	Tags []string
+}
+
+// ConvertPage converts Markdown content like Convert, along with the page
+// metadata it holds
+func (c *Converter) ConvertPage(markdown []byte) (*Page, []Diagnostic, error) {
+	return c.ConvertPageContext(context.Background(), markdown)
+}
+
+// ConvertPageContext converts Markdown content like ConvertPage, uploading
+// images with ctx
+func (c *Converter) ConvertPageContext(ctx context.Context, markdown []byte) (*Page, []Diagnostic, error) {
+	conv := c.begin()
+	conv.ctx = ctx
+	blocks, diagnostics, err := conv.convert(markdown)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Page{Blocks: blocks, Tags: conv.tags}, diagnostics, nil
+}
+
+// convert converts a whole document to Notion blocks using the receiver's state
+func (c *Converter) convert(markdown []byte) ([]notion.Block, []Diagnostic, error) {
+	doc, err := c.convertDocument(markdown)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pipeline := append(document.Pipeline{c.anchors}, c.transforms...)
+	pipeline = append(pipeline, render.Limits(c.verbose)...)
+	if err := pipeline.Apply(doc, c.reportBlock(markdown)); err != nil {
+		return nil, nil, fmt.Errorf("failed to transform document: %w", err)
+	}
+
+	return render.Notion(doc), c.sortedDiagnostics(), nil
+}
+
+// Parse parses Markdown content into a document, without the transforms
+// Convert applies before rendering it for Notion
+func (c *Converter) Parse(markdown []byte) (*document.Document, []Diagnostic, error) {
+	conv := c.begin()
+
+	doc, err := conv.convertDocument(markdown)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, conv.sortedDiagnostics(), nil
+}
+
+// begin returns a copy of the converter with fresh per-document state
+func (c *Converter) begin() *Converter {
+	conv := *c
+	conv.nodes = make(map[*document.Block]ast.Node)
+	conv.lineStarts = nil
+	conv.diagnostics = nil
+	conv.ctx = context.Background()
+	conv.tags = nil
+	conv.anchors = document.NewHeadingAnchors()
+	conv.firstLine = 1
+	conv.footnotes = 0
+	conv.lastFootnote = 0
+	conv.references = nil
+	return &conv
+}
+
+// convertDocument converts a whole document using the receiver's state
+func (c *Converter) convertDocument(markdown []byte) (*document.Document, error) {
+	root := c.parse(markdown)
+
+	doc := &document.Document{}
+	for child := root.FirstChild(); child != nil; child = child.NextSibling() {
 		nodeBlocks, err := c.convertNode(child, markdown)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert node: %w", err)
 		}
-		blocks = append(blocks, nodeBlocks...)
+		doc.Blocks = append(doc.Blocks, nodeBlocks...)
 	}
+	doc.Tags = c.tags
 
-	return blocks, nil
+	return doc, nil
 }
 
-// convertNode converts a single AST node to one or more Notion blocks
-func (c *Converter) convertNode(node ast.Node, source []byte) ([]notion.Block, error) {
-	switch n := node.(type) {
-	case *ast.Heading:
-		block, err := c.convertHeading(n, source)
-		if err != nil || block == nil {
-			return nil, err
-		}
-		return []notion.Block{*block}, nil
-	case *ast.Paragraph:
-		block, err := c.convertParagraph(n, source)
-		if err != nil || block == nil {
-			return nil, err
-		}
-		return []notion.Block{*block}, nil
-	case *ast.List:
-		block, err := c.convertList(n, source)
-		if err != nil || block == nil {
-			return nil, err
-		}
-		return []notion.Block{*block}, nil
-	case *ast.Blockquote:
-		block, err := c.convertBlockquote(n, source)
-		if err != nil || block == nil {
-			return nil, err
-		}
-		return []notion.Block{*block}, nil
-	case *ast.CodeBlock:
-		blocks, err := c.convertCodeBlock(n, source)
-		if err != nil {
-			return nil, err
-		}
-		return blocks, nil
-	case *ast.FencedCodeBlock:
-		blocks, err := c.convertFencedCodeBlock(n, source)
-		if err != nil {
-			return nil, err
-		}
-		return blocks, nil
-	case *ast.ThematicBreak:
-		block, err := c.convertThematicBreak()
-		if err != nil || block == nil {
-			return nil, err
-		}
-		return []notion.Block{*block}, nil
-	case *ast.HTMLBlock:
-		// Skip HTML blocks for simplicity
-		return []notion.Block{}, nil
-	case *extast.Table:
-		return c.convertTable(n, source)
-	default:
-		// Skip unknown node types
-		return []notion.Block{}, nil
+// parse parses a whole document. Within a stream, the document resolves the
+// link reference definitions of earlier segments.
+func (c *Converter) parse(markdown []byte) ast.Node {
+	if c.references == nil {
+		return c.parser.Parse(text.NewReader(markdown))
 	}
+
+	pc := parser.NewContext()
+	for _, ref := range c.references.links {
+		pc.AddReference(ref)
+	}
+	root := c.parser.Parse(text.NewReader(markdown), parser.WithContext(pc))
+	c.references.update(c, pc, root, markdown)
+	return root
 }
 
-// convertHeading converts heading nodes
-func (c *Converter) convertHeading(node *ast.Heading, source []byte) (*notion.Block, error) {
-	richText, err := c.convertInlineNodes(node, source)
+// convertNode converts a single AST node to one or more blocks, each
+// carrying the range of source lines the node was parsed from
+func (c *Converter) convertNode(node ast.Node, source []byte) ([]*document.Block, error) {
+	blocks, err := c.convertBlockNode(node, source)
 	if err != nil {
 		return nil, err
 	}
+	setSource(blocks, c.sourceRange(node, source))
+	c.setNode(blocks, node)
+	return blocks, nil
+}
 
-	heading := &notion.Heading{RichText: richText}
-	block := &notion.Block{Object: "block"}
+// convertBlockNode dispatches a block node to the handler registered for its kind
+func (c *Converter) convertBlockNode(node ast.Node, source []byte) ([]*document.Block, error) {
+	if handler, ok := c.blockHandlers[node.Kind()]; ok {
+		return handler(c, node, source)
+	}
 
-	switch node.Level {
-	case 1:
-		block.Type = "heading_1"
-		block.Heading1 = heading
-	case 2:
-		block.Type = "heading_2"
-		block.Heading2 = heading
-	case 3:
-		block.Type = "heading_3"
-		block.Heading3 = heading
-	default:
-		// Default to heading_3 for h4+ levels
-		block.Type = "heading_3"
-		block.Heading3 = heading
+	// Skip unknown node types
+	c.report(node, source, SeverityWarning, KindUnsupportedNode,
+		fmt.Sprintf("unsupported %s block dropped", node.Kind()))
+	return nil, nil
+}
+
+// convertHeading converts heading nodes
+func (c *Converter) convertHeading(node *ast.Heading, source []byte) (*document.Block, error) {
+	spans, err := c.convertInlineNodes(node, source)
+	if err != nil {
+		return nil, err
 	}
 
-	return block, nil
+	return &document.Block{
+		Kind:  document.KindHeading,
+		Level: node.Level,
+		Spans: spans,
+	}, nil
+}
+
+// Slugify turns heading text into an anchor the way GitHub does: lower case,
+// punctuation dropped and spaces replaced with hyphens
+func Slugify(text string) string {
+	return document.Slugify(text)
 }
 
 // convertParagraph converts paragraph nodes
-func (c *Converter) convertParagraph(node *ast.Paragraph, source []byte) (*notion.Block, error) {
+func (c *Converter) convertParagraph(node *ast.Paragraph, source []byte) (*document.Block, error) {
 	// Check if this paragraph contains only an image
 	if node.ChildCount() == 1 {
 		if img, ok := node.FirstChild().(*ast.Image); ok {
@@ -143,213 +338,197 @@ func (c *Converter) convertParagraph(node *ast.Paragraph, source []byte) (*notio
 		}
 	}
 
-	richText, err := c.convertInlineNodes(node, source)
+	spans, err := c.convertInlineNodes(node, source)
 	if err != nil {
 		return nil, err
 	}
 
 	// Skip empty paragraphs
-	if len(richText) == 0 {
+	if len(spans) == 0 {
 		return nil, nil
 	}
 
-	return &notion.Block{
-		Object:    "block",
-		Type:      "paragraph",
-		Paragraph: &notion.Paragraph{RichText: richText},
-	}, nil
+	return &document.Block{Kind: document.KindParagraph, Spans: spans}, nil
 }
 
-// convertList converts list nodes
-func (c *Converter) convertList(node *ast.List, source []byte) (*notion.Block, error) {
-	// Lists in Notion are handled as individual list items, not as containers
-	// We'll return the first item and let the caller handle siblings
-	if node.FirstChild() == nil {
-		return nil, nil
+// convertList converts list nodes. Notion has no list container, so every
+// item becomes a block of its own.
+func (c *Converter) convertList(node *ast.List, source []byte) ([]*document.Block, error) {
+	var blocks []*document.Block
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		item, ok := child.(*ast.ListItem)
+		if !ok {
+			continue
+		}
+		block, err := c.convertListItem(item, node.IsOrdered(), source)
+		if err != nil {
+			return nil, err
+		}
+		itemBlocks := []*document.Block{block}
+		setSource(itemBlocks, c.sourceRange(item, source))
+		c.setNode(itemBlocks, item)
+		blocks = append(blocks, block)
 	}
-
-	firstItem := node.FirstChild().(*ast.ListItem)
-	return c.convertListItem(firstItem, node.IsOrdered(), source)
+	return blocks, nil
 }
 
-// convertListItem converts a single list item
-func (c *Converter) convertListItem(node *ast.ListItem, isOrdered bool, source []byte) (*notion.Block, error) {
-	richText, err := c.convertInlineNodes(node, source)
-	if err != nil {
-		return nil, err
-	}
-
-	// Handle nested lists
-	var children []notion.Block
+// convertListItem converts a single list item. The text of its first
+// paragraph becomes the text of the item, and the blocks that follow, such
+// as nested lists, its children.
+func (c *Converter) convertListItem(node *ast.ListItem, isOrdered bool, source []byte) (*document.Block, error) {
+	var spans []document.Span
+	var children []*document.Block
 	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
-		if list, ok := child.(*ast.List); ok {
-			for item := list.FirstChild(); item != nil; item = item.NextSibling() {
-				if listItem, ok := item.(*ast.ListItem); ok {
-					childBlock, err := c.convertListItem(listItem, list.IsOrdered(), source)
-					if err != nil {
-						return nil, err
-					}
-					if childBlock != nil {
-						children = append(children, *childBlock)
-					}
-				}
+		if child == node.FirstChild() && (child.Kind() == ast.KindTextBlock || child.Kind() == ast.KindParagraph) {
+			var err error
+			if spans, err = c.convertInlineNodes(child, source); err != nil {
+				return nil, err
 			}
+			continue
 		}
-	}
 
-	block := &notion.Block{Object: "block"}
-
-	if isOrdered {
-		block.Type = "numbered_list_item"
-		block.NumberedListItem = &notion.NumberedListItem{
-			RichText: richText,
-			Children: children,
-		}
-	} else {
-		block.Type = "bulleted_list_item"
-		block.BulletedListItem = &notion.BulletedListItem{
-			RichText: richText,
-			Children: children,
+		childBlocks, err := c.convertNode(child, source)
+		if err != nil {
+			return nil, err
 		}
+		children = append(children, childBlocks...)
 	}
 
+	block := &document.Block{
+		Kind:     document.KindBulletedItem,
+		Spans:    spans,
+		Children: children,
+	}
+	if isOrdered {
+		block.Kind = document.KindNumberedItem
+	}
+	if checked, ok := taskCheckBox(node); ok {
+		block.Kind = document.KindToDo
+		block.Checked = checked
+	}
 	return block, nil
 }
 
 // convertBlockquote converts blockquote nodes
-func (c *Converter) convertBlockquote(node *ast.Blockquote, source []byte) (*notion.Block, error) {
-	richText, err := c.convertInlineNodes(node, source)
+func (c *Converter) convertBlockquote(node *ast.Blockquote, source []byte) (*document.Block, error) {
+	spans, err := c.convertInlineNodes(node, source)
 	if err != nil {
 		return nil, err
 	}
 
-	return &notion.Block{
-		Object: "block",
-		Type:   "quote",
-		Quote:  &notion.Quote{RichText: richText},
-	}, nil
+	return &document.Block{Kind: document.KindQuote, Spans: spans}, nil
 }
 
 // convertCodeBlock converts indented code blocks
-func (c *Converter) convertCodeBlock(node *ast.CodeBlock, source []byte) ([]notion.Block, error) {
-	var content strings.Builder
-	lines := node.Lines()
-	for i := 0; i < lines.Len(); i++ {
-		line := lines.At(i)
-		content.Write(line.Value(source))
-	}
-
-	return c.createCodeBlocks(content.String(), "plain text"), nil
+func (c *Converter) convertCodeBlock(node *ast.CodeBlock, source []byte) (*document.Block, error) {
+	block := codeBlock(node, source, "")
+	block.Language = c.detectCodeLanguage(node, source, block.Text())
+	return block, nil
 }
 
 // convertFencedCodeBlock converts fenced code blocks
-func (c *Converter) convertFencedCodeBlock(node *ast.FencedCodeBlock, source []byte) ([]notion.Block, error) {
-	var content strings.Builder
-	lines := node.Lines()
-	for i := 0; i < lines.Len(); i++ {
-		line := lines.At(i)
-		content.Write(line.Value(source))
-	}
-
+func (c *Converter) convertFencedCodeBlock(node *ast.FencedCodeBlock, source []byte) (*document.Block, error) {
 	var language string
 	if node.Info != nil {
 		language = string(node.Info.Text(source))
 	}
 
-	// Map common language names to Notion's expected values
-	language = c.mapLanguage(language)
-
-	return c.createCodeBlocks(content.String(), language), nil
-}
-
-// createCodeBlocks splits large code content into multiple blocks if needed
-// Notion API has a 2000 character limit for code block content
-func (c *Converter) createCodeBlocks(content, language string) []notion.Block {
-	const (
-		maxChars = 2000
-		// minDistanceFromLimit defines the minimum acceptable distance from the character limit
-		// when breaking at a newline. This ensures we don't create unnecessarily small chunks
-		// just to break at a newline. A value of 200 means we'll accept breaking up to 200 chars
-		// before the limit to preserve line integrity.
-		minDistanceFromLimit = 200
-	)
-
-	// If content fits in one block, return it
-	if len(content) <= maxChars {
-		return []notion.Block{{
-			Object: "block",
-			Type:   "code",
-			Code: &notion.Code{
-				RichText: []notion.RichText{{
-					Type: "text",
-					Text: &notion.Text{Content: content},
-				}},
-				Language: language,
-				Caption:  []notion.RichText{},
-			},
-		}}
+	if block, ok := c.convertDataTable(node, source, language); ok {
+		return block, nil
+	}
+	if fields := strings.Fields(language); len(fields) > 0 {
+		// Data that is not a table is kept as plain text, as Notion has no
+		// language for it
+		if _, ok := dataSeparators[strings.ToLower(fields[0])]; ok {
+			return codeBlock(node, source, "plain text"), nil
+		}
 	}
 
-	// Need to split into multiple blocks
-	if c.verbose {
-		fmt.Fprintf(os.Stderr, "Splitting code block of %d characters into multiple blocks\n", len(content))
+	if strings.TrimSpace(language) == "" {
+		block := codeBlock(node, source, "")
+		block.Language = c.detectCodeLanguage(node, source, block.Text())
+		return block, nil
 	}
 
-	var blocks []notion.Block
-	for len(content) > 0 {
-		chunkSize := maxChars
-		if len(content) < maxChars {
-			chunkSize = len(content)
-		}
+	// Map common language names to Notion's expected values
+	language = c.mapLanguage(language)
+	if !notionLanguages[language] {
+		c.report(node, source, SeverityWarning, KindUnknownLanguage,
+			fmt.Sprintf("code language %q is not supported by Notion", language))
+	}
 
-		// Try to break at a newline to avoid splitting lines
-		if chunkSize == maxChars && chunkSize < len(content) {
-			// Look for the last newline before maxChars
-			lastNewline := strings.LastIndex(content[:chunkSize], "\n")
-			if lastNewline > 0 && lastNewline > maxChars-minDistanceFromLimit {
-				// Only break at newline if it's reasonably close to maxChars
-				chunkSize = lastNewline + 1
-			}
-		}
+	return codeBlock(node, source, language), nil
+}
 
-		chunk := content[:chunkSize]
-		content = content[chunkSize:]
-
-		blocks = append(blocks, notion.Block{
-			Object: "block",
-			Type:   "code",
-			Code: &notion.Code{
-				RichText: []notion.RichText{{
-					Type: "text",
-					Text: &notion.Text{Content: chunk},
-				}},
-				Language: language,
-				Caption:  []notion.RichText{},
-			},
-		})
+// codeBlock builds a code block from the lines of a code node. Code longer
+// than Notion's limit is split when the document is rendered.
+func codeBlock(node ast.Node, source []byte, language string) *document.Block {
+	var content strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		content.Write(line.Value(source))
 	}
 
-	return blocks
+	return &document.Block{
+		Kind:     document.KindCode,
+		Spans:    []document.Span{document.Text(content.String())},
+		Language: language,
+	}
 }
 
 // convertThematicBreak converts horizontal rules
-func (c *Converter) convertThematicBreak() (*notion.Block, error) {
-	return &notion.Block{
-		Object:  "block",
-		Type:    "divider",
-		Divider: &notion.Divider{},
-	}, nil
+func (c *Converter) convertThematicBreak() (*document.Block, error) {
+	return &document.Block{Kind: document.KindDivider}, nil
 }
 
 // convertImage converts image nodes
-func (c *Converter) convertImage(node *ast.Image, source []byte) (*notion.Block, error) {
+func (c *Converter) convertImage(node *ast.Image, source []byte) (*document.Block, error) {
 	src := string(node.Destination)
 
+	caption, err := c.imageCaption(node, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// Embedded and local images go to the image host when one is configured,
+	// otherwise they are uploaded to Notion before publishing
+	file, err := c.loadImage(src)
+	if err != nil {
+		c.report(node, source, SeverityWarning, KindImageSkipped, err.Error())
+		return nil, nil
+	}
+	if file != nil {
+		if c.imageUploader != nil {
+			hosted, err := c.imageUploader.Upload(c.ctx, *file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to upload image %s: %w", src, err)
+			}
+			if hosted == "" {
+				c.report(node, source, SeverityWarning, KindImageSkipped,
+					fmt.Sprintf("image %s left out by the image host", src))
+				return nil, nil
+			}
+			if c.verbose {
+				fmt.Fprintf(os.Stderr, "Hosted image %s at %s\n", src, hosted)
+			}
+			return imageBlock(&document.Image{URL: hosted, Caption: caption}), nil
+		}
+
+		return imageBlock(&document.Image{
+			File: &document.File{
+				Name:        file.Name,
+				ContentType: file.ContentType,
+				Data:        file.Data,
+			},
+			Caption: caption,
+		}), nil
+	}
+
 	// Skip invalid or unsupported image URLs
 	if !c.isValidImageURL(src) {
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "Skipping invalid/unsupported image URL: %s\n", src)
-		}
+		c.report(node, source, SeverityWarning, KindImageSkipped,
+			fmt.Sprintf("invalid or unsupported image URL %q", src))
 		return nil, nil
 	}
 
@@ -357,254 +536,208 @@ func (c *Converter) convertImage(node *ast.Image, source []byte) (*notion.Block,
 	if !c.isAbsoluteURL(src) {
 		if c.imageBaseURL == "" {
 			// Skip relative images without base URL
+			c.report(node, source, SeverityWarning, KindImageSkipped,
+				fmt.Sprintf("relative image %s has no base URL", src))
 			return nil, nil
 		}
 		src = c.joinURL(c.imageBaseURL, src)
 	}
 
-	// Get alt text as caption
-	var caption []notion.RichText
-	if node.FirstChild() != nil {
-		if text, ok := node.FirstChild().(*ast.Text); ok {
-			caption = []notion.RichText{{
-				Type: "text",
-				Text: &notion.Text{Content: string(text.Segment.Value(source))},
-			}}
-		} else if str, ok := node.FirstChild().(*ast.String); ok {
-			caption = []notion.RichText{{
-				Type: "text",
-				Text: &notion.Text{Content: string(str.Value)},
-			}}
-		}
+	return imageBlock(&document.Image{URL: src, Caption: caption}), nil
+}
+
+// imageBlock wraps an image in a block
+func imageBlock(img *document.Image) *document.Block {
+	return &document.Block{Kind: document.KindImage, Image: img}
+}
+
+// imageCaption builds the caption of an image from its title when it has
+// one, otherwise from the formatted alt text
+func (c *Converter) imageCaption(node *ast.Image, source []byte) ([]document.Span, error) {
+	if c.noCaptions {
+		return nil, nil
 	}
 
-	return &notion.Block{
-		Object: "block",
-		Type:   "image",
-		Image: &notion.Image{
-			Type:     "external",
-			External: &notion.External{URL: src},
-			Caption:  caption,
-		},
-	}, nil
+	if title := strings.TrimSpace(string(node.Title)); title != "" {
+		return []document.Span{document.Text(title)}, nil
+	}
+
+	return c.convertInlineNodes(node, source)
 }
 
-// convertTable converts table nodes to native Notion table blocks
-func (c *Converter) convertTable(node *extast.Table, source []byte) ([]notion.Block, error) {
-	var tableWidth int
-	var tableRows []notion.Block
-	hasColumnHeader := false
+// convertFootnoteList converts the collected footnote definitions into a
+// notes section: a divider followed by one numbered list item per note
+func (c *Converter) convertFootnoteList(node *extast.FootnoteList, source []byte) ([]*document.Block, error) {
+	blocks := []*document.Block{{Kind: document.KindDivider}}
 
 	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
-		switch n := child.(type) {
-		case *extast.TableHeader:
-			// Handle table header row
-			hasColumnHeader = true
-
-			// Count columns from header
-			if tableWidth == 0 {
-				for cell := n.FirstChild(); cell != nil; cell = cell.NextSibling() {
-					if _, ok := cell.(*extast.TableCell); ok {
-						tableWidth++
-					}
-				}
-			}
-
-			// Convert header to table_row block
-			rowBlock, err := c.convertTableHeaderToBlock(n, source)
-			if err != nil {
-				return nil, err
-			}
-			tableRows = append(tableRows, *rowBlock)
+		footnote, ok := child.(*extast.Footnote)
+		if !ok {
+			continue
+		}
 
-		case *extast.TableRow:
-			// Handle regular table rows
-
-			// Count columns from first row if not already done
-			if tableWidth == 0 {
-				for cell := n.FirstChild(); cell != nil; cell = cell.NextSibling() {
-					if _, ok := cell.(*extast.TableCell); ok {
-						tableWidth++
-					}
+		// The first paragraph becomes the item text, anything else is nested
+		var spans []document.Span
+		var children []*document.Block
+		hasText := false
+		for fc := footnote.FirstChild(); fc != nil; fc = fc.NextSibling() {
+			if para, ok := fc.(*ast.Paragraph); ok && !hasText {
+				texts, err := c.convertInlineNodes(para, source)
+				if err != nil {
+					return nil, err
 				}
+				spans = texts
+				hasText = true
+				continue
 			}
-
-			// Convert row to table_row block
-			rowBlock, err := c.convertTableRowToBlock(n, source)
+			childBlocks, err := c.convertNode(fc, source)
 			if err != nil {
 				return nil, err
 			}
-			tableRows = append(tableRows, *rowBlock)
+			children = append(children, childBlocks...)
 		}
+
+		c.lastFootnote = max(c.lastFootnote, c.footnotes+footnote.Index)
+		item := &document.Block{
+			Kind:     document.KindNumberedItem,
+			Spans:    spans,
+			Children: children,
+			Anchor:   footnoteAnchor(c.footnotes + footnote.Index),
+			Source:   c.sourceRange(footnote, source),
+		}
+		c.nodes[item] = footnote
+		blocks = append(blocks, item)
 	}
 
-	// Create the table block with table_row children inside the Table struct
-	tableBlock := notion.Block{
-		Object: "block",
-		Type:   "table",
-		Table: &notion.Table{
-			TableWidth:      tableWidth,
-			HasColumnHeader: hasColumnHeader,
-			HasRowHeader:    false,
-			Children:        tableRows, // Children go inside the Table struct
-		},
+	return blocks, nil
+}
+
+// convertFootnoteLink renders a footnote reference as a superscript number
+func (c *Converter) convertFootnoteLink(node *extast.FootnoteLink) document.Span {
+	index := c.footnotes + node.Index
+	span := document.Text(superscript(index))
+	if c.linkFootnotes {
+		span.Href = "#" + footnoteAnchor(index)
 	}
+	return span
+}
 
-	// Return just the table block with rows as children
-	return []notion.Block{tableBlock}, nil
+// footnoteAnchor returns the anchor name of the note block for a footnote
+func footnoteAnchor(index int) string {
+	return fmt.Sprintf("fn-%d", index)
 }
 
-// convertTableHeaderToBlock converts a table header to a table_row block
-func (c *Converter) convertTableHeaderToBlock(header *extast.TableHeader, source []byte) (*notion.Block, error) {
-	var cells [][]notion.RichText
+// superscript formats a number using Unicode superscript digits
+func superscript(n int) string {
+	const digits = "⁰¹²³⁴⁵⁶⁷⁸⁹"
+	var b strings.Builder
+	for _, r := range strconv.Itoa(n) {
+		b.WriteString(string([]rune(digits)[r-'0']))
+	}
+	return b.String()
+}
 
-	for cell := header.FirstChild(); cell != nil; cell = cell.NextSibling() {
-		if tableCell, ok := cell.(*extast.TableCell); ok {
-			cellRichText, err := c.convertTableCellToRichText(tableCell, source)
-			if err != nil {
-				return nil, err
-			}
-			cells = append(cells, cellRichText)
+// convertTable converts table nodes to native Notion table blocks
+func (c *Converter) convertTable(node *extast.Table, source []byte) (*document.Block, error) {
+	hasHeader := false
+	var rows []*document.Block
+
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child.(type) {
+		case *extast.TableHeader:
+			hasHeader = true
+		case *extast.TableRow:
+		default:
+			continue
+		}
+
+		row, err := c.convertTableRow(child, source)
+		if err != nil {
+			return nil, err
 		}
+		rows = append(rows, row)
 	}
 
-	return &notion.Block{
-		Object: "block",
-		Type:   "table_row",
-		TableRow: &notion.TableRow{
-			Cells: cells,
-		},
-	}, nil
+	return tableBlock(rows, hasHeader), nil
 }
 
-// convertTableRowToBlock converts a table row to a table_row block
-func (c *Converter) convertTableRowToBlock(row *extast.TableRow, source []byte) (*notion.Block, error) {
-	var cells [][]notion.RichText
+// tableBlock builds a table block from its rows, the first row being the
+// column header when hasHeader is set. Short rows are padded with empty
+// cells. Tables over Notion's row limit are split when the document is
+// rendered.
+func tableBlock(rows []*document.Block, hasHeader bool) *document.Block {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row.Cells))
+	}
+	for _, row := range rows {
+		for len(row.Cells) < width {
+			row.Cells = append(row.Cells, nil)
+		}
+	}
+
+	return &document.Block{
+		Kind:     document.KindTable,
+		Table:    &document.Table{Width: width, HasColumnHeader: hasHeader},
+		Children: rows,
+	}
+}
+
+// convertTableRow converts a table header or row to a table row block
+func (c *Converter) convertTableRow(row ast.Node, source []byte) (*document.Block, error) {
+	var cells [][]document.Span
 
 	for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
 		if tableCell, ok := cell.(*extast.TableCell); ok {
-			cellRichText, err := c.convertTableCellToRichText(tableCell, source)
+			spans, err := c.convertInlineNodes(tableCell, source)
 			if err != nil {
 				return nil, err
 			}
-			cells = append(cells, cellRichText)
+			cells = append(cells, spans)
 		}
 	}
 
-	return &notion.Block{
-		Object: "block",
-		Type:   "table_row",
-		TableRow: &notion.TableRow{
-			Cells: cells,
-		},
-	}, nil
-}
-
-// convertTableCellToRichText converts a table cell to rich text
-func (c *Converter) convertTableCellToRichText(cell *extast.TableCell, source []byte) ([]notion.RichText, error) {
-	// Use the existing inline conversion logic
-	richTexts, err := c.convertInlineNodes(cell, source)
-	if err != nil {
-		return nil, err
-	}
-
-	// If no content, return a single empty text element
-	if len(richTexts) == 0 {
-		return []notion.RichText{{
-			Type: "text",
-			Text: &notion.Text{Content: ""},
-		}}, nil
+	block := &document.Block{
+		Kind:   document.KindTableRow,
+		Cells:  cells,
+		Source: c.sourceRange(row, source),
 	}
-
-	return richTexts, nil
+	c.nodes[block] = row
+	return block, nil
 }
 
-// convertInlineNodes converts child nodes to rich text
-func (c *Converter) convertInlineNodes(parent ast.Node, source []byte) ([]notion.RichText, error) {
-	var richText []notion.RichText
+// convertInlineNodes converts child nodes to spans
+func (c *Converter) convertInlineNodes(parent ast.Node, source []byte) ([]document.Span, error) {
+	var spans []document.Span
 
 	for child := parent.FirstChild(); child != nil; child = child.NextSibling() {
 		texts, err := c.convertInlineNode(child, source)
 		if err != nil {
 			return nil, err
 		}
-		richText = append(richText, texts...)
+		spans = append(spans, texts...)
 	}
 
-	return richText, nil
+	if c.typography != nil {
+		// The typographer splits text around every substitution
+		spans = mergeSpans(spans)
+	}
+	return spans, nil
 }
 
-// convertInlineNode converts a single inline node
-func (c *Converter) convertInlineNode(node ast.Node, source []byte) ([]notion.RichText, error) {
-	switch n := node.(type) {
-	case *ast.String:
-		return []notion.RichText{{
-			Type: "text",
-			Text: &notion.Text{Content: string(n.Value)},
-		}}, nil
-
-	case *ast.Text:
-		return []notion.RichText{{
-			Type: "text",
-			Text: &notion.Text{Content: string(n.Segment.Value(source))},
-		}}, nil
-
-	case *ast.CodeSpan:
-		return []notion.RichText{{
-			Type:        "text",
-			Text:        &notion.Text{Content: string(n.Text(source))},
-			Annotations: &notion.Annotations{Code: true},
-		}}, nil
-
-	case *ast.Emphasis:
-		texts, err := c.convertInlineNodes(n, source)
-		if err != nil {
-			return nil, err
-		}
-		// Apply formatting based on emphasis level (1=italic, 2=bold)
-		for i := range texts {
-			if texts[i].Annotations == nil {
-				texts[i].Annotations = &notion.Annotations{}
-			}
-			if n.Level == 2 {
-				texts[i].Annotations.Bold = true
-			} else {
-				texts[i].Annotations.Italic = true
-			}
-		}
-		return texts, nil
-
-	case *ast.Link:
-		texts, err := c.convertInlineNodes(n, source)
-		if err != nil {
-			return nil, err
-		}
-		// Apply link
-		href := string(n.Destination)
-		for i := range texts {
-			texts[i].Href = &href
-		}
-		return texts, nil
-
-	case *ast.AutoLink:
-		href := string(n.URL(source))
-		return []notion.RichText{{
-			Type: "text",
-			Text: &notion.Text{Content: href},
-			Href: &href,
-		}}, nil
-
-	case *ast.Image:
-		// Images in inline context are skipped (handled at paragraph level)
-		return nil, nil
+// convertInlineNode dispatches an inline node to the handler registered for its kind
+func (c *Converter) convertInlineNode(node ast.Node, source []byte) ([]document.Span, error) {
+	if handler, ok := c.inlineHandlers[node.Kind()]; ok {
+		return handler(c, node, source)
+	}
 
-	default:
-		// For other inline elements, try to extract text content
-		if n.HasChildren() {
-			return c.convertInlineNodes(n, source)
-		}
-		return nil, nil
+	// For other inline elements, try to extract text content
+	if node.HasChildren() {
+		return c.convertInlineNodes(node, source)
 	}
+	c.report(node, source, SeverityWarning, KindUnsupportedNode,
+		fmt.Sprintf("unsupported %s element dropped", node.Kind()))
+	return nil, nil
 }
 
 // mapLanguage maps common language identifiers to Notion's expected values
@@ -644,6 +777,25 @@ func (c *Converter) mapLanguage(lang string) string {
 	}
 }
 
+// notionLanguages lists the code block languages accepted by the Notion API
+var notionLanguages = map[string]bool{
+	"abap": true, "arduino": true, "bash": true, "basic": true, "c": true,
+	"clojure": true, "coffeescript": true, "c++": true, "c#": true, "css": true,
+	"dart": true, "diff": true, "docker": true, "elixir": true, "elm": true,
+	"erlang": true, "flow": true, "fortran": true, "f#": true, "gherkin": true,
+	"glsl": true, "go": true, "graphql": true, "groovy": true, "haskell": true,
+	"html": true, "java": true, "javascript": true, "json": true, "julia": true,
+	"kotlin": true, "latex": true, "less": true, "lisp": true, "livescript": true,
+	"lua": true, "makefile": true, "markdown": true, "markup": true, "matlab": true,
+	"mermaid": true, "nix": true, "objective-c": true, "ocaml": true, "pascal": true,
+	"perl": true, "php": true, "plain text": true, "powershell": true, "prolog": true,
+	"protobuf": true, "python": true, "r": true, "reason": true, "ruby": true,
+	"rust": true, "sass": true, "scala": true, "scheme": true, "scss": true,
+	"shell": true, "sql": true, "swift": true, "typescript": true, "vb.net": true,
+	"verilog": true, "vhdl": true, "visual basic": true, "webassembly": true,
+	"xml": true, "yaml": true, "java/c/c++/c#": true,
+}
+
 // isAbsoluteURL checks if a URL is absolute
 func (c *Converter) isAbsoluteURL(urlStr string) bool {
 	u, err := url.Parse(urlStr)
@@ -673,7 +825,7 @@ func (c *Converter) isValidImageURL(urlStr string) bool {
 		return false
 	}
 
-	// Skip data URLs (base64 images)
+	// Skip data URLs that could not be decoded for upload
 	if strings.HasPrefix(urlStr, "data:") {
 		return false
 	}
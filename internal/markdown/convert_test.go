@@ -3,6 +3,7 @@ package markdown
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/wiremind/markdown-to-notionapi/internal/notion"
@@ -78,6 +79,29 @@ func TestConverter_Convert(t *testing.T) {
 						RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Item 1"}}},
 					},
 				},
+				{
+					Object: "block",
+					Type:   "bulleted_list_item",
+					BulletedListItem: &notion.BulletedListItem{
+						RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Item 2"}}},
+						Children: []notion.Block{
+							{
+								Object: "block",
+								Type:   "bulleted_list_item",
+								BulletedListItem: &notion.BulletedListItem{
+									RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Nested item"}}},
+								},
+							},
+						},
+					},
+				},
+				{
+					Object: "block",
+					Type:   "bulleted_list_item",
+					BulletedListItem: &notion.BulletedListItem{
+						RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Item 3"}}},
+					},
+				},
 			},
 		},
 		{
@@ -91,6 +115,29 @@ func TestConverter_Convert(t *testing.T) {
 						RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "First item"}}},
 					},
 				},
+				{
+					Object: "block",
+					Type:   "numbered_list_item",
+					NumberedListItem: &notion.NumberedListItem{
+						RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Second item"}}},
+						Children: []notion.Block{
+							{
+								Object: "block",
+								Type:   "numbered_list_item",
+								NumberedListItem: &notion.NumberedListItem{
+									RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Nested item"}}},
+								},
+							},
+						},
+					},
+				},
+				{
+					Object: "block",
+					Type:   "numbered_list_item",
+					NumberedListItem: &notion.NumberedListItem{
+						RichText: []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Third item"}}},
+					},
+				},
 			},
 		},
 		{
@@ -222,22 +269,12 @@ func TestConverter_Convert(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := NewConverter(tt.baseURL, false)
-			got, err := c.Convert([]byte(tt.markdown))
+			got, _, err := c.Convert([]byte(tt.markdown))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Converter.Convert() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
-			// For list tests, we only check the first block since lists are complex
-			if tt.name == "bulleted list" || tt.name == "numbered list" {
-				if len(got) == 0 {
-					t.Errorf("Converter.Convert() got no blocks")
-					return
-				}
-				compareBlock(t, got[0], tt.want[0])
-				return
-			}
-
 			if len(got) != len(tt.want) {
 				t.Errorf("Converter.Convert() got %d blocks, want %d", len(got), len(tt.want))
 				return
@@ -400,6 +437,13 @@ func compareBulletedListItem(t *testing.T, got, want *notion.BulletedListItem) {
 		return
 	}
 	compareRichText(t, got.RichText, want.RichText)
+	if len(got.Children) != len(want.Children) {
+		t.Errorf("BulletedListItem has %d children, want %d", len(got.Children), len(want.Children))
+		return
+	}
+	for i := range got.Children {
+		compareBlock(t, got.Children[i], want.Children[i])
+	}
 }
 
 func compareNumberedListItem(t *testing.T, got, want *notion.NumberedListItem) {
@@ -411,6 +455,13 @@ func compareNumberedListItem(t *testing.T, got, want *notion.NumberedListItem) {
 		return
 	}
 	compareRichText(t, got.RichText, want.RichText)
+	if len(got.Children) != len(want.Children) {
+		t.Errorf("NumberedListItem has %d children, want %d", len(got.Children), len(want.Children))
+		return
+	}
+	for i := range got.Children {
+		compareBlock(t, got.Children[i], want.Children[i])
+	}
 }
 
 func compareQuote(t *testing.T, got, want *notion.Quote) {
@@ -472,6 +523,17 @@ func compareRichText(t *testing.T, got, want []notion.RichText) {
 	}
 }
 
+// plainText concatenates the content of rich text items
+func plainText(richText []notion.RichText) string {
+	var b strings.Builder
+	for _, rt := range richText {
+		if rt.Text != nil {
+			b.WriteString(rt.Text.Content)
+		}
+	}
+	return b.String()
+}
+
 func compareRichTextItem(t *testing.T, got, want notion.RichText) {
 	t.Helper()
 	if got.Type != want.Type {
@@ -533,7 +595,7 @@ func TestConverter_LargeCodeBlock(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := NewConverter("", false)
-			blocks, err := c.Convert([]byte(tt.markdown))
+			blocks, _, err := c.Convert([]byte(tt.markdown))
 			if err != nil {
 				t.Fatalf("Convert() error = %v", err)
 			}
@@ -568,3 +630,198 @@ func TestConverter_LargeCodeBlock(t *testing.T) {
 		})
 	}
 }
+
+func TestConverter_Footnotes(t *testing.T) {
+	markdown := "Claim one[^a] and claim two[^b].\n\n[^a]: First *note*.\n[^b]: Second note."
+
+	tests := []struct {
+		name      string
+		linkNotes bool
+		wantHref  string
+	}{
+		{name: "plain references", linkNotes: false},
+		{name: "linked references", linkNotes: true, wantHref: "#fn-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("", false, WithFootnoteLinks(tt.linkNotes))
+			blocks, _, err := c.Convert([]byte(markdown))
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+
+			// paragraph, divider, two notes
+			if len(blocks) != 4 {
+				t.Fatalf("Convert() returned %d blocks, want 4", len(blocks))
+			}
+
+			para := blocks[0].Paragraph.RichText
+			if len(para) != 5 {
+				t.Fatalf("paragraph has %d rich text items, want 5", len(para))
+			}
+			ref := para[1]
+			if ref.Text.Content != "¹" {
+				t.Errorf("reference content = %q, want %q", ref.Text.Content, "¹")
+			}
+			if tt.wantHref == "" && ref.Href != nil {
+				t.Errorf("reference href = %q, want none", *ref.Href)
+			}
+			if tt.wantHref != "" && (ref.Href == nil || *ref.Href != tt.wantHref) {
+				t.Errorf("reference href = %v, want %q", ref.Href, tt.wantHref)
+			}
+
+			if blocks[1].Type != "divider" {
+				t.Errorf("block 1 type = %q, want divider", blocks[1].Type)
+			}
+			compareBlock(t, blocks[2], notion.Block{
+				Object: "block",
+				Type:   "numbered_list_item",
+				NumberedListItem: &notion.NumberedListItem{
+					RichText: []notion.RichText{
+						{Type: "text", Text: &notion.Text{Content: "First "}},
+						{Type: "text", Text: &notion.Text{Content: "note"}, Annotations: &notion.Annotations{Italic: true}},
+						{Type: "text", Text: &notion.Text{Content: "."}},
+					},
+				},
+			})
+			if blocks[2].Anchor != "fn-1" || blocks[3].Anchor != "fn-2" {
+				t.Errorf("note anchors = %q, %q, want fn-1, fn-2", blocks[2].Anchor, blocks[3].Anchor)
+			}
+		})
+	}
+}
+
+func TestSuperscript(t *testing.T) {
+	if got := superscript(1024); got != "¹⁰²⁴" {
+		t.Errorf("superscript(1024) = %q, want %q", got, "¹⁰²⁴")
+	}
+}
+
+func TestConverter_EmojiShortcodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []notion.RichText
+	}{
+		{
+			name:     "shortcodes in text",
+			markdown: "Shipped :rocket: :white_check_mark:",
+			want: []notion.RichText{
+				{Type: "text", Text: &notion.Text{Content: "Shipped "}},
+				{Type: "text", Text: &notion.Text{Content: "🚀"}},
+				{Type: "text", Text: &notion.Text{Content: " "}},
+				{Type: "text", Text: &notion.Text{Content: "✅"}},
+			},
+		},
+		{
+			name:     "shortcode in code span",
+			markdown: "Use `:rocket:` literally",
+			want: []notion.RichText{
+				{Type: "text", Text: &notion.Text{Content: "Use "}},
+				{Type: "text", Text: &notion.Text{Content: ":rocket:"}, Annotations: &notion.Annotations{Code: true}},
+				{Type: "text", Text: &notion.Text{Content: " literally"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("", false)
+			blocks, _, err := c.Convert([]byte(tt.markdown))
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if len(blocks) != 1 || blocks[0].Paragraph == nil {
+				t.Fatalf("Convert() = %+v, want a single paragraph", blocks)
+			}
+			compareRichText(t, blocks[0].Paragraph.RichText, tt.want)
+		})
+	}
+
+	// Unknown shortcodes are kept as typed
+	c := NewConverter("", false)
+	blocks, _, err := c.Convert([]byte("Time :not_an_emoji:"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if got := plainText(blocks[0].Paragraph.RichText); got != "Time :not_an_emoji:" {
+		t.Errorf("paragraph text = %q, want %q", got, "Time :not_an_emoji:")
+	}
+
+	// Code blocks keep shortcodes untouched
+	blocks, _, err = c.Convert([]byte("```\n:rocket:\n```"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if got := blocks[0].Code.RichText[0].Text.Content; got != ":rocket:\n" {
+		t.Errorf("code block content = %q, want %q", got, ":rocket:\n")
+	}
+}
+
+func TestEmojiIcon(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *notion.Icon
+		wantErr bool
+	}{
+		{value: ":rocket:", want: &notion.Icon{Type: "emoji", Emoji: "🚀"}},
+		{value: "memo", want: &notion.Icon{Type: "emoji", Emoji: "📝"}},
+		{value: "📚", want: &notion.Icon{Type: "emoji", Emoji: "📚"}},
+		{value: "👍🏽", want: &notion.Icon{Type: "emoji", Emoji: "👍🏽"}},
+		{value: "🇫🇷", want: &notion.Icon{Type: "emoji", Emoji: "🇫🇷"}},
+		{value: "", want: nil},
+		{value: "hello", wantErr: true},
+		{value: ":typo:", wantErr: true},
+		{value: "🚀🚀", wantErr: true},
+		{value: "é", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := EmojiIcon(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EmojiIcon(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EmojiIcon(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverter_HeadingAnchors(t *testing.T) {
+	markdown := "# Getting Started\n\n## Installation\n\nSee [setup](#installation).\n\n## Installation\n\n### What's new in v2.0?\n"
+
+	c := NewConverter("", false)
+	blocks, _, err := c.Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	var anchors []string
+	for _, block := range blocks {
+		if strings.HasPrefix(block.Type, "heading_") {
+			anchors = append(anchors, block.Anchor)
+		}
+	}
+	want := []string{"getting-started", "installation", "installation-1", "whats-new-in-v20"}
+	if !reflect.DeepEqual(anchors, want) {
+		t.Errorf("heading anchors = %q, want %q", anchors, want)
+	}
+
+	// Anchor links are kept as-is until the page is uploaded
+	link := blocks[2].Paragraph.RichText[1]
+	if link.Href == nil || *link.Href != "#installation" {
+		t.Errorf("anchor link href = %v, want %q", link.Href, "#installation")
+	}
+
+	// Anchors are numbered per document, not per converter
+	again, _, err := c.Convert([]byte("## Installation\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if again[0].Anchor != "installation" {
+		t.Errorf("anchor on second conversion = %q, want %q", again[0].Anchor, "installation")
+	}
+}
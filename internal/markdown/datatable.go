@@ -0,0 +1,111 @@
+// internal/markdown/datatable.go
+package markdown
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark/ast"
+)
+
+// dataSeparators maps the languages of fenced code blocks holding
+// delimited data to their field separator
+var dataSeparators = map[string]rune{
+	"csv": ',',
+	"tsv": '\t',
+}
+
+// convertDataTable converts a ```csv or ```tsv fenced code block to a
+// table. The fence info may hold header=true or header=false to set
+// whether the first row is the column header, which is otherwise guessed.
+// It reports false when the block does not hold valid delimited data, to be
+// kept as a code block.
+func (c *Converter) convertDataTable(node *ast.FencedCodeBlock, source []byte, info string) (*document.Block, bool) {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	format := strings.ToLower(fields[0])
+	separator, ok := dataSeparators[format]
+	if !ok {
+		return nil, false
+	}
+
+	header := ""
+	for _, option := range fields[1:] {
+		name, value, _ := strings.Cut(option, "=")
+		if name == "header" {
+			header = value
+		}
+	}
+
+	records, err := parseDelimited(codeBlock(node, source, "").Text(), separator)
+	if err != nil {
+		c.report(node, source, SeverityInfo, KindInvalidTableData,
+			fmt.Sprintf("%s data kept as a code block: %v", format, err))
+		return nil, false
+	}
+	if len(records) == 0 {
+		return nil, false
+	}
+
+	var hasHeader bool
+	switch header {
+	case "true", "yes":
+		hasHeader = true
+	case "false", "no":
+		hasHeader = false
+	default:
+		hasHeader = looksLikeHeader(records)
+	}
+
+	rows := make([]*document.Block, 0, len(records))
+	for _, record := range records {
+		cells := make([][]document.Span, len(record))
+		for i, field := range record {
+			if field != "" {
+				cells[i] = []document.Span{document.Text(field)}
+			}
+		}
+		rows = append(rows, &document.Block{Kind: document.KindTableRow, Cells: cells})
+	}
+	return tableBlock(rows, hasHeader), true
+}
+
+// parseDelimited parses records quoted as in RFC 4180, with fields
+// separated by separator. Records may have different numbers of fields.
+func parseDelimited(data string, separator rune) ([][]string, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comma = separator
+	r.FieldsPerRecord = -1
+	return r.ReadAll()
+}
+
+// looksLikeHeader guesses whether the first record names the columns: its
+// fields must all be distinct non-numeric text, and it must be followed by
+// other records
+func looksLikeHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, field := range records[0] {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] || isNumeric(field) {
+			return false
+		}
+		seen[field] = true
+	}
+	return true
+}
+
+// isNumeric reports whether a field holds a number, possibly a percentage
+// or with thousands separators
+func isNumeric(field string) bool {
+	field = strings.TrimSuffix(strings.ReplaceAll(field, ",", ""), "%")
+	_, err := strconv.ParseFloat(field, 64)
+	return err == nil
+}
@@ -0,0 +1,112 @@
+// internal/markdown/datatable_test.go
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConverter_DataTables(t *testing.T) {
+	tests := []struct {
+		name       string
+		markdown   string
+		wantHeader bool
+		wantRows   [][]string
+	}{
+		{
+			name:       "csv with header",
+			markdown:   "```csv\nservice,p99 (ms)\nbilling,\"1,200\"\n\"api \"\"v2\"\"\",85\n```\n",
+			wantHeader: true,
+			wantRows:   [][]string{{"service", "p99 (ms)"}, {"billing", "1,200"}, {`api "v2"`, "85"}},
+		},
+		{
+			name:     "numeric first row",
+			markdown: "```csv\n2023,12\n2024,15\n```\n",
+			wantRows: [][]string{{"2023", "12"}, {"2024", "15"}},
+		},
+		{
+			name:     "header forced off",
+			markdown: "```csv header=false\nname,role\nalice,dev\n```\n",
+			wantRows: [][]string{{"name", "role"}, {"alice", "dev"}},
+		},
+		{
+			name:       "tsv with short rows",
+			markdown:   "```tsv\nregion\tusers\tgrowth\neu\t120\n```\n",
+			wantHeader: true,
+			wantRows:   [][]string{{"region", "users", "growth"}, {"eu", "120", ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, diagnostics, err := NewConverter("", false).Convert([]byte(tt.markdown))
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if len(diagnostics) != 0 {
+				t.Errorf("got diagnostics %v", diagnostics)
+			}
+			if len(blocks) != 1 || blocks[0].Table == nil {
+				t.Fatalf("got %+v, want a table", blocks)
+			}
+
+			table := blocks[0].Table
+			if table.HasColumnHeader != tt.wantHeader || table.TableWidth != len(tt.wantRows[0]) {
+				t.Errorf("table header = %v width = %d", table.HasColumnHeader, table.TableWidth)
+			}
+			var rows [][]string
+			for _, row := range table.Children {
+				var cells []string
+				for _, cell := range row.TableRow.Cells {
+					cells = append(cells, plainText(cell))
+				}
+				rows = append(rows, cells)
+			}
+			if fmt.Sprint(rows) != fmt.Sprint(tt.wantRows) {
+				t.Errorf("rows = %q, want %q", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestConverter_DataTableLimits(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("```csv\nid,value\n")
+	for i := 0; i < 150; i++ {
+		fmt.Fprintf(&data, "%d,%d\n", i, i*2)
+	}
+	data.WriteString("```\n\n```csv\nbroken,\"quote\n```\n")
+
+	blocks, diagnostics, err := NewConverter("", false).Convert([]byte(data.String()))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if len(blocks) != 3 || blocks[0].Table == nil || blocks[1].Table == nil || blocks[2].Code == nil {
+		t.Fatalf("got %d blocks, want the table split in 2 and the broken data kept as code", len(blocks))
+	}
+	if rows := len(blocks[1].Table.Children); rows != 1+150-99 {
+		t.Errorf("second table has %d rows", rows)
+	}
+
+	var kinds []string
+	for _, d := range diagnostics {
+		kinds = append(kinds, d.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != KindTableSplit+","+KindInvalidTableData {
+		t.Errorf("diagnostics = %v", diagnostics)
+	}
+	if language := blocks[2].Code.Language; language != "plain text" {
+		t.Errorf("broken data kept as %q code, want plain text", language)
+	}
+
+	// Invalid data alone is not lossy
+	_, diagnostics, err = NewConverter("", false).Convert([]byte("```tsv header=true\n\"open\tquote\n```\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Kind != KindInvalidTableData || diagnostics[0].Lossy() {
+		t.Errorf("diagnostics = %v, want only an informational %s", diagnostics, KindInvalidTableData)
+	}
+}
@@ -0,0 +1,95 @@
+// internal/markdown/definitions.go
+package markdown
+
+import (
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+// WithDefinitionToggles converts the terms of definition lists to toggles
+// holding their definitions, instead of bold paragraphs
+func WithDefinitionToggles(enabled bool) Option {
+	return func(c *Converter) {
+		c.definitionToggles = enabled
+	}
+}
+
+// convertDefinitionList converts a definition list to a block per term,
+// with the term's definitions as its children:
+//
+//	Term
+//	: Definition
+//
+// Terms become bold paragraphs, or toggles with WithDefinitionToggles.
+func (c *Converter) convertDefinitionList(node *extast.DefinitionList, source []byte) ([]*document.Block, error) {
+	var blocks []*document.Block
+	var term *document.Block
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child := child.(type) {
+		case *extast.DefinitionTerm:
+			spans, err := c.convertInlineNodes(child, source)
+			if err != nil {
+				return nil, err
+			}
+			term = c.definitionTerm(spans)
+			setSource([]*document.Block{term}, c.sourceRange(child, source))
+			c.setNode([]*document.Block{term}, child)
+			blocks = append(blocks, term)
+
+		case *extast.DefinitionDescription:
+			definitions, err := c.convertDefinition(child, source)
+			if err != nil {
+				return nil, err
+			}
+			if term == nil {
+				blocks = append(blocks, definitions...)
+				continue
+			}
+			term.Children = append(term.Children, definitions...)
+		}
+	}
+	return blocks, nil
+}
+
+// definitionTerm returns the block of a term with its inline formatting
+func (c *Converter) definitionTerm(spans []document.Span) *document.Block {
+	if c.definitionToggles {
+		return &document.Block{Kind: document.KindToggle, Spans: spans}
+	}
+	bold := make([]document.Span, len(spans))
+	for i, span := range spans {
+		span.Marks.Bold = true
+		bold[i] = span
+	}
+	return &document.Block{Kind: document.KindParagraph, Spans: bold}
+}
+
+// convertDefinition converts the blocks of a definition. The text of tight
+// definitions, written without blank lines around them, becomes a paragraph.
+func (c *Converter) convertDefinition(node *extast.DefinitionDescription, source []byte) ([]*document.Block, error) {
+	var blocks []*document.Block
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if child.Kind() != ast.KindTextBlock {
+			childBlocks, err := c.convertNode(child, source)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, childBlocks...)
+			continue
+		}
+
+		spans, err := c.convertInlineNodes(child, source)
+		if err != nil {
+			return nil, err
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		paragraph := []*document.Block{{Kind: document.KindParagraph, Spans: spans}}
+		setSource(paragraph, c.sourceRange(child, source))
+		c.setNode(paragraph, child)
+		blocks = append(blocks, paragraph...)
+	}
+	return blocks, nil
+}
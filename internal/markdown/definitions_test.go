@@ -0,0 +1,59 @@
+// internal/markdown/definitions_test.go
+package markdown
+
+import "testing"
+
+func TestConverter_DefinitionLists(t *testing.T) {
+	markdown := "*API* key\n: A **secret** token.\n: Sent as a header.\n\nLatency\n\n:   Time taken.\n\n        slow\n"
+
+	blocks, diagnostics, err := NewConverter("", false).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("got diagnostics %v", diagnostics)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want a block per term", len(blocks))
+	}
+
+	term := blocks[0]
+	if term.Paragraph == nil || len(term.Paragraph.RichText) != 2 {
+		t.Fatalf("term = %+v, want a paragraph", term)
+	}
+	for _, rt := range term.Paragraph.RichText {
+		if rt.Annotations == nil || !rt.Annotations.Bold {
+			t.Errorf("term text %q is not bold", rt.Text.Content)
+		}
+	}
+	if !term.Paragraph.RichText[0].Annotations.Italic {
+		t.Error("term lost its italic text")
+	}
+	definitions := term.Paragraph.Children
+	if len(definitions) != 2 || definitions[0].Paragraph == nil || definitions[1].Paragraph == nil {
+		t.Fatalf("definitions = %+v, want two paragraphs", definitions)
+	}
+	if secret := definitions[0].Paragraph.RichText[1]; secret.Text.Content != "secret" || !secret.Annotations.Bold {
+		t.Errorf("definition lost its formatting: %+v", secret)
+	}
+	if term.Source.StartLine != 1 || definitions[1].Source.StartLine != 3 {
+		t.Errorf("source lines = %d and %d, want 1 and 3", term.Source.StartLine, definitions[1].Source.StartLine)
+	}
+
+	loose := blocks[1].Paragraph.Children
+	if len(loose) != 2 || loose[0].Paragraph == nil || loose[1].Code == nil {
+		t.Errorf("loose definition = %+v, want a paragraph and code", loose)
+	}
+
+	blocks, _, err = NewConverter("", false, WithDefinitionToggles(true)).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	toggle := blocks[0].Toggle
+	if blocks[0].Type != "toggle" || toggle == nil || len(toggle.Children) != 2 {
+		t.Fatalf("term = %+v, want a toggle holding the definitions", blocks[0])
+	}
+	if toggle.RichText[1].Annotations != nil {
+		t.Errorf("toggle text is formatted: %+v", toggle.RichText[1])
+	}
+}
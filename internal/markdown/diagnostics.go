@@ -0,0 +1,189 @@
+// internal/markdown/diagnostics.go
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/wiremind/markdown-to-notionapi/internal/render"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Severity tells how much a diagnostic matters
+type Severity = document.Severity
+
+const (
+	// SeverityInfo marks content that was altered but kept, e.g. a split code block
+	SeverityInfo = document.SeverityInfo
+	// SeverityWarning marks content that did not make it into Notion
+	SeverityWarning = document.SeverityWarning
+)
+
+// Diagnostic kinds reported by the converter
+const (
+	KindHTMLDropped       = "html_dropped"
+	KindUnsupportedNode   = "unsupported_node"
+	KindImageSkipped      = "image_skipped"
+	KindHeadingDowngraded = render.KindHeadingDowngraded
+	KindCodeSplit         = render.KindCodeSplit
+	KindTableSplit        = render.KindTableSplit
+	KindUnknownLanguage   = "unknown_language"
+	KindInvalidTableData  = "invalid_table_data"
+	KindInvalidRawBlock   = "invalid_notion_json"
+	KindLanguageDetected  = "language_detected"
+	// KindUnresolvedReference marks a footnote or link reference of a
+	// streamed document that is defined in another segment
+	KindUnresolvedReference = "unresolved_reference"
+)
+
+// lossyKinds lists the diagnostic kinds where source content is lost or
+// no longer matches the Markdown
+var lossyKinds = map[string]bool{
+	KindHTMLDropped:         true,
+	KindUnsupportedNode:     true,
+	KindImageSkipped:        true,
+	KindHeadingDowngraded:   true,
+	KindCodeSplit:           true,
+	KindTableSplit:          true,
+	KindUnknownLanguage:     true,
+	KindInvalidRawBlock:     true,
+	KindUnresolvedReference: true,
+}
+
+// Diagnostic describes content the converter dropped or altered
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	Message  string   `json:"message"`
+	// Line and Column are 1-based; zero when the position is unknown
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// Lossy reports whether the diagnostic means the Notion page will not hold
+// exactly what the Markdown says
+func (d Diagnostic) Lossy() bool {
+	return lossyKinds[d.Kind]
+}
+
+// String formats the diagnostic as "line:col: severity: message"
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s (%s)", d.Severity, d.Message, d.Kind)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Line, d.Column, d.Severity, d.Message, d.Kind)
+}
+
+// maxExcerptLength bounds the source excerpt attached to diagnostics
+const maxExcerptLength = 80
+
+// report records a diagnostic for node in the current document
+func (c *Converter) report(node ast.Node, source []byte, severity Severity, kind, message string) {
+	d := c.diagnosticAt(source, nodeOffset(node), severity, kind, message)
+	c.diagnostics = append(c.diagnostics, d)
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "%s\n", d)
+	}
+}
+
+// diagnosticAt returns a diagnostic located at a source offset, or without
+// a position when offset is -1
+func (c *Converter) diagnosticAt(source []byte, offset int, severity Severity, kind, message string) Diagnostic {
+	d := Diagnostic{Severity: severity, Kind: kind, Message: message}
+
+	if offset >= 0 && offset <= len(source) {
+		lineStart := bytes.LastIndexByte(source[:offset], '\n') + 1
+		lineEnd := bytes.IndexByte(source[offset:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(source)
+		} else {
+			lineEnd += offset
+		}
+
+		d.Line = c.lineAt(source, offset)
+		d.Column = utf8.RuneCount(source[lineStart:offset]) + 1
+		d.Excerpt = excerpt(string(source[lineStart:lineEnd]))
+	}
+
+	return d
+}
+
+// reportBlock returns the report function of document transforms, which
+// locates diagnostics at the node the block was converted from
+func (c *Converter) reportBlock(source []byte) document.ReportFunc {
+	return func(b *document.Block, severity Severity, kind, message string) {
+		if node, ok := c.nodes[b]; ok {
+			c.report(node, source, severity, kind, message)
+			return
+		}
+
+		// Blocks added by transforms only have the lines they stand for
+		d := Diagnostic{Severity: severity, Kind: kind, Message: message}
+		if !b.Source.IsZero() {
+			d.Line, d.Column = b.Source.StartLine, 1
+		}
+		c.diagnostics = append(c.diagnostics, d)
+		if c.verbose {
+			fmt.Fprintf(os.Stderr, "%s\n", d)
+		}
+	}
+}
+
+// sortedDiagnostics returns the diagnostics in source order, as transforms
+// report theirs after the whole document was converted
+func (c *Converter) sortedDiagnostics() []Diagnostic {
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i], c.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.diagnostics
+}
+
+// nodeOffset finds the source offset where a node starts, or -1
+func nodeOffset(node ast.Node) int {
+	for n := node; n != nil; n = n.Parent() {
+		if offset := firstOffset(n); offset >= 0 {
+			return offset
+		}
+	}
+	return -1
+}
+
+// firstOffset returns the first source offset covered by a node or its descendants
+func firstOffset(node ast.Node) int {
+	switch n := node.(type) {
+	case *ast.Text:
+		return n.Segment.Start
+	case *ast.RawHTML:
+		if n.Segments.Len() > 0 {
+			return n.Segments.At(0).Start
+		}
+	}
+	if node.Type() == ast.TypeBlock && node.Lines().Len() > 0 {
+		return node.Lines().At(0).Start
+	}
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if offset := firstOffset(child); offset >= 0 {
+			return offset
+		}
+	}
+	return -1
+}
+
+// excerpt trims a source line to a readable length
+func excerpt(line string) string {
+	line = strings.TrimSpace(line)
+	if utf8.RuneCountInString(line) <= maxExcerptLength {
+		return line
+	}
+	return string([]rune(line)[:maxExcerptLength]) + "..."
+}
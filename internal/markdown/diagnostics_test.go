@@ -0,0 +1,104 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConverter_Diagnostics(t *testing.T) {
+	markdown := strings.Join([]string{
+		"# Title",
+		"",
+		"<div align=\"center\">badge</div>",
+		"",
+		"#### Deep heading",
+		"",
+		"Text with <br> and ![inline](https://example.com/a.png) image.",
+		"",
+		"![relative](missing/diagram.png)",
+		"",
+		"```",
+		strings.Repeat("x\n", 1500),
+		"```",
+	}, "\n")
+
+	c := NewConverter("", false)
+	_, diagnostics, err := c.Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := []Diagnostic{
+		{Severity: SeverityWarning, Kind: KindHTMLDropped, Line: 3, Column: 1, Excerpt: `<div align="center">badge</div>`},
+		{Severity: SeverityInfo, Kind: KindHeadingDowngraded, Line: 5, Column: 6, Excerpt: "#### Deep heading"},
+		{Severity: SeverityWarning, Kind: KindHTMLDropped, Line: 7, Column: 11},
+		{Severity: SeverityWarning, Kind: KindImageSkipped, Line: 7, Column: 22},
+		{Severity: SeverityWarning, Kind: KindImageSkipped, Line: 9, Column: 3},
+		{Severity: SeverityInfo, Kind: KindCodeSplit, Line: 12, Column: 1, Excerpt: "x"},
+	}
+	if len(diagnostics) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diagnostics), len(want), diagnostics)
+	}
+	for i, d := range diagnostics {
+		w := want[i]
+		if d.Severity != w.Severity || d.Kind != w.Kind || d.Line != w.Line || d.Column != w.Column {
+			t.Errorf("diagnostic %d = %s, want %s at %d:%d", i, d, w.Kind, w.Line, w.Column)
+		}
+		if w.Excerpt != "" && d.Excerpt != w.Excerpt {
+			t.Errorf("diagnostic %d excerpt = %q, want %q", i, d.Excerpt, w.Excerpt)
+		}
+		if d.Message == "" {
+			t.Errorf("diagnostic %d has no message", i)
+		}
+	}
+}
+
+func TestConverter_NoDiagnosticsForSupportedContent(t *testing.T) {
+	c := NewConverter("", false)
+	_, diagnostics, err := c.Convert([]byte("# Title\n\nSome **bold** text.\n\n- item\n\n> quote\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("got diagnostics %v, want none", diagnostics)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("é", maxExcerptLength+10)
+	got := excerpt("  " + long + "  ")
+	if want := strings.Repeat("é", maxExcerptLength) + "..."; got != want {
+		t.Errorf("excerpt() = %q, want %q", got, want)
+	}
+}
+
+func TestConverter_UnknownCodeLanguage(t *testing.T) {
+	c := NewConverter("", false)
+	_, diagnostics, err := c.Convert([]byte("```golang\nx\n```\n\n```brainfuck\n+\n```\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(diagnostics) != 1 {
+		t.Fatalf("got diagnostics %v, want one", diagnostics)
+	}
+	if d := diagnostics[0]; d.Kind != KindUnknownLanguage || d.Line != 6 || !strings.Contains(d.Message, "brainfuck") {
+		t.Errorf("diagnostic = %s, want unknown brainfuck language on line 6", d)
+	}
+}
+
+func TestDiagnostic_Lossy(t *testing.T) {
+	tests := map[string]bool{
+		KindHTMLDropped:       true,
+		KindUnsupportedNode:   true,
+		KindImageSkipped:      true,
+		KindCodeSplit:         true,
+		KindUnknownLanguage:   true,
+		KindHeadingDowngraded: true,
+		KindLanguageDetected:  false,
+	}
+	for kind, want := range tests {
+		if got := (Diagnostic{Kind: kind}).Lossy(); got != want {
+			t.Errorf("Diagnostic{Kind: %q}.Lossy() = %v, want %v", kind, got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// internal/markdown/dialect.go
+package markdown
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+// Dialect is a flavour of Markdown with syntax beyond CommonMark
+type Dialect string
+
+// Markdown dialects
+const (
+	// DialectGFM adds GitHub's strikethrough, bare URL links and task lists
+	DialectGFM Dialect = "gfm"
+	// DialectObsidian adds the syntax of Obsidian vaults to GFM: ![[embeds]],
+	// [[wikilinks]], #tags and %%comments%%
+	DialectObsidian Dialect = "obsidian"
+	// DialectMkDocs adds the syntax of MkDocs Material to GFM: !!! admonitions,
+	// ??? collapsible admonitions and === "Tab" content tabs
+	DialectMkDocs Dialect = "mkdocs"
+)
+
+// ParseDialect returns the dialect of a name; "" is plain Markdown
+func ParseDialect(name string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(strings.TrimSpace(name))); d {
+	case "", DialectGFM, DialectObsidian, DialectMkDocs:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown Markdown dialect %q (want gfm, obsidian or mkdocs)", name)
+}
+
+// WithDialect parses the syntax of a Markdown dialect, converted to the
+// closest Notion blocks
+func WithDialect(d Dialect) Option {
+	return func(c *Converter) {
+		c.dialect = d
+	}
+}
+
+// extensions returns the parser extensions of the dialect
+func (d Dialect) extensions() []goldmark.Extender {
+	gfm := []goldmark.Extender{extension.Strikethrough, extension.Linkify, extension.TaskList}
+	switch d {
+	case DialectGFM:
+		return gfm
+	case DialectObsidian:
+		return append(gfm, obsidian{})
+	case DialectMkDocs:
+		return append(gfm, mkdocs{})
+	}
+	return nil
+}
+
+// convertStrikethrough converts ~~deleted~~ text
+func (c *Converter) convertStrikethrough(node *extast.Strikethrough, source []byte) ([]document.Span, error) {
+	spans, err := c.convertInlineNodes(node, source)
+	if err != nil {
+		return nil, err
+	}
+	for i := range spans {
+		spans[i].Marks.Strikethrough = true
+	}
+	return spans, nil
+}
+
+// taskCheckBox returns the state of the checkbox opening a task list item
+func taskCheckBox(item *ast.ListItem) (checked, ok bool) {
+	text := item.FirstChild()
+	if text == nil {
+		return false, false
+	}
+	box, ok := text.FirstChild().(*extast.TaskCheckBox)
+	if !ok {
+		return false, false
+	}
+	return box.IsChecked, true
+}
@@ -0,0 +1,166 @@
+// internal/markdown/dialect_test.go
+package markdown
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// richText returns the text of rich text items
+func richText(items []notion.RichText) string {
+	var text string
+	for _, rt := range items {
+		text += rt.Text.Content
+	}
+	return text
+}
+
+func TestParseDialect(t *testing.T) {
+	for name, want := range map[string]Dialect{"": "", "GFM": DialectGFM, " obsidian": DialectObsidian, "mkdocs": DialectMkDocs} {
+		if got, err := ParseDialect(name); err != nil || got != want {
+			t.Errorf("ParseDialect(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseDialect("hugo"); err == nil {
+		t.Error("ParseDialect(hugo) succeeded")
+	}
+}
+
+func TestConverter_GFMDialect(t *testing.T) {
+	markdown := "- [x] Done ~~not~~\n\n- [ ] Open\n"
+
+	blocks, _, err := NewConverter("", false, WithDialect(DialectGFM)).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].ToDo == nil || blocks[1].ToDo == nil {
+		t.Fatalf("blocks = %+v, want two to-dos", blocks)
+	}
+	todo := blocks[0].ToDo
+	if !todo.Checked || richText(todo.RichText) != "Done not" {
+		t.Errorf("to-do = %+v, want checked \"Done not\"", todo)
+	}
+	if last := todo.RichText[len(todo.RichText)-1]; last.Annotations == nil || !last.Annotations.Strikethrough {
+		t.Errorf("%q is not struck through", last.Text.Content)
+	}
+	if open := blocks[1].ToDo; open.Checked || richText(open.RichText) != "Open" {
+		t.Errorf("to-do = %+v, want unchecked \"Open\"", open)
+	}
+
+	// Plain Markdown keeps the checkbox as text
+	blocks, _, err = NewConverter("", false).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if blocks[0].ToDo != nil {
+		t.Errorf("plain Markdown converted a task list: %+v", blocks[0])
+	}
+}
+
+func TestConverter_ObsidianDialect(t *testing.T) {
+	markdown := "See [[Other Note#Set Up]], [[page|the page]] and #project/alpha, not #42 or a#b. %%hidden%%#project/alpha\n" +
+		"\n![[diagrams/flow chart.png|300]]\n\n%%\nA comment\n%%\n\nEnd #todo\n"
+
+	page, _, err := NewConverter("https://example.com", false, WithDialect(DialectObsidian)).ConvertPage([]byte(markdown))
+	if err != nil {
+		t.Fatalf("ConvertPage() error = %v", err)
+	}
+	if !slices.Equal(page.Tags, []string{"project/alpha", "todo"}) {
+		t.Errorf("tags = %q, want project/alpha and todo", page.Tags)
+	}
+	blocks := page.Blocks
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want a paragraph, an image and a paragraph", len(blocks))
+	}
+
+	text := blocks[0].Paragraph.RichText
+	if got, want := richText(text), "See Other Note > Set Up, the page and #project/alpha, not #42 or a#b. #project/alpha"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if text[1].Href == nil || *text[1].Href != "Other%20Note.md#set-up" || text[3].Href == nil || *text[3].Href != "page.md" {
+		t.Errorf("links = %+v and %+v", text[1], text[3])
+	}
+
+	image := blocks[1].Image
+	if image == nil || image.External == nil || image.External.URL != "https://example.com/diagrams/flow%20chart.png" {
+		t.Errorf("image = %+v, want the embedded image", blocks[1])
+	}
+	if got := richText(blocks[2].Paragraph.RichText); got != "End #todo" {
+		t.Errorf("text after the comment block = %q", got)
+	}
+}
+
+func TestConverter_ObsidianLinkMap(t *testing.T) {
+	root := t.TempDir()
+	links, err := NewLinkMap(root, map[string]string{
+		"Other Note.md": "0123456789abcdef0123456789abcdef",
+		"faq.md":        "https://wiki.example.com/faq",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConverter("", false, WithDialect(DialectObsidian), WithSourceFile(filepath.Join(root, "guide.md")), WithLinkMap(links))
+	blocks, _, err := c.Convert([]byte("See [[Other Note#Set Up]], [[Other Note|the note]] and [[faq#Billing]].\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	var hrefs []string
+	for _, rt := range blocks[0].Paragraph.RichText {
+		if rt.Href != nil {
+			hrefs = append(hrefs, *rt.Href)
+		}
+	}
+	want := []string{
+		"https://notion.so/0123456789abcdef0123456789abcdef#set-up",
+		"https://notion.so/0123456789abcdef0123456789abcdef",
+		"https://wiki.example.com/faq#billing",
+	}
+	if !slices.Equal(hrefs, want) {
+		t.Errorf("links = %q, want %q", hrefs, want)
+	}
+}
+
+func TestConverter_MkDocsDialect(t *testing.T) {
+	markdown := "!!! warning \"Mind the gap\"\n    Between the **train**\n\n    and the platform.\n\n" +
+		"!!! tip \"\"\n    Untitled.\n\n" +
+		"??? note\n    Folded.\n\n" +
+		"=== \"Go\"\n    ```go\n    fmt.Println()\n    ```\n\n" +
+		"Unindented.\n"
+
+	blocks, _, err := NewConverter("", false, WithDialect(DialectMkDocs)).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 5 {
+		t.Fatalf("got %d blocks, want 5", len(blocks))
+	}
+
+	warning := blocks[0].Callout
+	if warning == nil || richText(warning.RichText) != "Mind the gap" || !warning.RichText[0].Annotations.Bold {
+		t.Fatalf("warning = %+v, want a callout with a bold title", blocks[0])
+	}
+	if warning.Icon == nil || warning.Icon.Emoji != "⚠️" || warning.Color != "yellow_background" || len(warning.Children) != 2 {
+		t.Errorf("warning = %+v, want a yellow callout holding two paragraphs", warning)
+	}
+
+	tip := blocks[1].Callout
+	if tip == nil || richText(tip.RichText) != "Untitled." || len(tip.Children) != 0 {
+		t.Errorf("tip = %+v, want its paragraph as the text", blocks[1])
+	}
+
+	if folded := blocks[2].Toggle; folded == nil || richText(folded.RichText) != "Note" || len(folded.Children) != 1 {
+		t.Errorf("collapsible admonition = %+v, want a toggle", blocks[2])
+	}
+	tab := blocks[3].Toggle
+	if tab == nil || richText(tab.RichText) != "Go" || len(tab.Children) != 1 || tab.Children[0].Code == nil {
+		t.Errorf("tab = %+v, want a toggle holding the code", blocks[3])
+	}
+	if blocks[4].Paragraph == nil {
+		t.Errorf("block after the tab = %+v, want a paragraph", blocks[4])
+	}
+}
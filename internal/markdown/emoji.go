@@ -0,0 +1,62 @@
+// internal/markdown/emoji.go
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+	"github.com/yuin/goldmark-emoji/definition"
+)
+
+// emojis holds the GitHub shortcode table, shared by the parser and icon lookups
+var emojis = definition.Github()
+
+// LookupEmoji returns the Unicode emoji for a shortcode such as ":rocket:".
+// The surrounding colons are optional.
+func LookupEmoji(shortcode string) (string, bool) {
+	name := strings.Trim(strings.TrimSpace(shortcode), ":")
+	if name == "" {
+		return "", false
+	}
+
+	emoji, ok := emojis.Get(name)
+	if !ok || !emoji.IsUnicode() {
+		return "", false
+	}
+	return string(emoji.Unicode), true
+}
+
+// EmojiIcon builds a Notion emoji icon from a shortcode or a literal emoji.
+// An empty value yields no icon. Anything else, such as plain text or an
+// unknown shortcode, is an error: Notion would only reject it once the page
+// exists.
+func EmojiIcon(value string) (*notion.Icon, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if emoji, ok := LookupEmoji(value); ok {
+		return &notion.Icon{Type: "emoji", Emoji: emoji}, nil
+	}
+	if !isEmoji(value) {
+		return nil, fmt.Errorf("icon %q is neither an emoji nor a known shortcode such as :rocket:", value)
+	}
+	return &notion.Icon{Type: "emoji", Emoji: value}, nil
+}
+
+// isEmoji reports whether value is a single emoji: one grapheme cluster
+// holding a pictographic symbol, which covers modifiers, flags and keycaps
+func isEmoji(value string) bool {
+	if uniseg.GraphemeClusterCount(value) != 1 {
+		return false
+	}
+	for _, r := range value {
+		if unicode.Is(unicode.So, r) || r == '\u20e3' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+// internal/markdown/extension.go
+package markdown
+
+import (
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+)
+
+// BlockHandler converts a block node to document blocks. c is the converter
+// of the current document and source the Markdown the node was parsed from.
+type BlockHandler func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error)
+
+// InlineHandler converts an inline node to spans
+type InlineHandler func(c *Converter, node ast.Node, source []byte) ([]document.Span, error)
+
+// WithExtensions adds goldmark extensions to the Markdown parser, e.g. to
+// parse custom syntax into nodes converted by handlers registered with
+// WithBlockHandler and WithInlineHandler
+func WithExtensions(extensions ...goldmark.Extender) Option {
+	return func(c *Converter) {
+		c.extensions = append(c.extensions, extensions...)
+	}
+}
+
+// WithBlockHandler converts block nodes of the given kind with h, replacing
+// any built-in conversion. A nil handler drops the nodes with a diagnostic.
+func WithBlockHandler(kind ast.NodeKind, h BlockHandler) Option {
+	return func(c *Converter) {
+		c.blockHandlers = cloneHandlers(c.blockHandlers)
+		if h == nil {
+			delete(c.blockHandlers, kind)
+			return
+		}
+		c.blockHandlers[kind] = h
+	}
+}
+
+// WithInlineHandler converts inline nodes of the given kind with h,
+// replacing any built-in conversion. With a nil handler the children of the
+// nodes are converted instead.
+func WithInlineHandler(kind ast.NodeKind, h InlineHandler) Option {
+	return func(c *Converter) {
+		c.inlineHandlers = cloneHandlers(c.inlineHandlers)
+		if h == nil {
+			delete(c.inlineHandlers, kind)
+			return
+		}
+		c.inlineHandlers[kind] = h
+	}
+}
+
+// WithTransforms rewrites every converted document with transforms before
+// it is rendered for Notion. They run after heading anchors are assigned
+// and before content is fitted within Notion's limits.
+func WithTransforms(transforms ...document.Transform) Option {
+	return func(c *Converter) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// cloneHandlers copies a handler map so options never change the handlers
+// of another converter
+func cloneHandlers[H any](handlers map[ast.NodeKind]H) map[ast.NodeKind]H {
+	clone := make(map[ast.NodeKind]H, len(handlers)+1)
+	for kind, h := range handlers {
+		clone[kind] = h
+	}
+	return clone
+}
+
+// ConvertBlock converts a block node with the registered handlers, for
+// handlers of container nodes converting their children
+func (c *Converter) ConvertBlock(node ast.Node, source []byte) ([]*document.Block, error) {
+	return c.convertNode(node, source)
+}
+
+// ConvertChildren converts the block children of a node
+func (c *Converter) ConvertChildren(node ast.Node, source []byte) ([]*document.Block, error) {
+	var blocks []*document.Block
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		childBlocks, err := c.convertNode(child, source)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, childBlocks...)
+	}
+	return blocks, nil
+}
+
+// ConvertInline converts the inline children of a node to spans
+func (c *Converter) ConvertInline(node ast.Node, source []byte) ([]document.Span, error) {
+	return c.convertInlineNodes(node, source)
+}
+
+// ResolveLink rewrites a link destination the way Markdown links are rewritten
+func (c *Converter) ResolveLink(href string) string {
+	return c.resolveLink(href)
+}
+
+// Report records a diagnostic for content a handler dropped or altered
+func (c *Converter) Report(node ast.Node, source []byte, severity Severity, kind, message string) {
+	c.report(node, source, severity, kind, message)
+}
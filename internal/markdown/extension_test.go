@@ -0,0 +1,135 @@
+package markdown
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// ticketNode is a ticket reference such as PROJ-123
+type ticketNode struct {
+	ast.BaseInline
+	Key string
+}
+
+var kindTicket = ast.NewNodeKind("Ticket")
+
+func (n *ticketNode) Kind() ast.NodeKind { return kindTicket }
+
+func (n *ticketNode) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+var ticketPattern = regexp.MustCompile(`^PROJ-\d+`)
+
+type ticketParser struct{}
+
+// Trigger fires at the start of lines and after spaces, as goldmark only
+// tries inline parsers on spaces and punctuation
+func (ticketParser) Trigger() []byte { return []byte{' '} }
+
+func (ticketParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	space := 0
+	if len(line) > 0 && util.IsSpace(line[0]) {
+		space = 1
+	}
+	key := ticketPattern.Find(line[space:])
+	if key == nil {
+		return nil
+	}
+	if space > 0 {
+		ast.MergeOrAppendTextSegment(parent, segment.WithStop(segment.Start+space))
+	}
+	block.Advance(space + len(key))
+	return &ticketNode{Key: string(key)}
+}
+
+type ticketExtension struct{}
+
+func (ticketExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(ticketParser{}, 999)))
+}
+
+func convertTicket(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+	key := node.(*ticketNode).Key
+	return []document.Span{{Text: key, Href: "https://tracker.example.com/browse/" + key}}, nil
+}
+
+func TestConverter_CustomInlineNodes(t *testing.T) {
+	c := NewConverter("", false,
+		WithExtensions(ticketExtension{}),
+		WithInlineHandler(kindTicket, convertTicket),
+	)
+
+	blocks, diagnostics, err := c.Convert([]byte("Fixed in PROJ-123, see Pending notes.\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("got diagnostics %v, want none", diagnostics)
+	}
+
+	richText := blocks[0].Paragraph.RichText
+	if got := plainText(richText); got != "Fixed in PROJ-123, see Pending notes." {
+		t.Errorf("text = %q", got)
+	}
+	var linked bool
+	for _, rt := range richText {
+		if rt.Text.Content == "PROJ-123" && rt.Href != nil && *rt.Href == "https://tracker.example.com/browse/PROJ-123" {
+			linked = true
+		}
+	}
+	if !linked {
+		t.Errorf("ticket reference is not linked: %+v", richText)
+	}
+}
+
+func TestConverter_CustomNodeWithoutHandler(t *testing.T) {
+	c := NewConverter("", false, WithExtensions(ticketExtension{}))
+	_, diagnostics, err := c.Convert([]byte("Fixed in PROJ-123.\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Kind != KindUnsupportedNode {
+		t.Errorf("got diagnostics %v, want one unsupported node", diagnostics)
+	}
+}
+
+func TestConverter_ReplaceBuiltinBlockHandler(t *testing.T) {
+	// Unwrap block quotes into their content
+	unwrap := func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+		return c.ConvertChildren(node, source)
+	}
+	c := NewConverter("", false,
+		WithBlockHandler(ast.KindBlockquote, unwrap),
+		WithBlockHandler(ast.KindThematicBreak, nil),
+	)
+
+	blocks, diagnostics, err := c.Convert([]byte("> quoted\n\n---\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].Type != "paragraph" {
+		t.Fatalf("got %d blocks, want the quote unwrapped into a paragraph", len(blocks))
+	}
+	if blocks[0].Source.StartLine != 1 {
+		t.Errorf("paragraph source = %s, want line 1", blocks[0].Source)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Kind != KindUnsupportedNode {
+		t.Errorf("got diagnostics %v, want the thematic break dropped", diagnostics)
+	}
+
+	// Other converters keep the built-in conversions
+	blocks, _, err = NewConverter("", false).Convert([]byte("> quoted\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].Type != "quote" {
+		t.Errorf("built-in block quote conversion was changed")
+	}
+}
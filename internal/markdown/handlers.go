@@ -0,0 +1,170 @@
+// internal/markdown/handlers.go
+package markdown
+
+import (
+	"fmt"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	emojiast "github.com/yuin/goldmark-emoji/ast"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+// builtinBlockHandlers returns the conversions of the block nodes produced
+// by the standard parser and the built-in extensions
+func builtinBlockHandlers() map[ast.NodeKind]BlockHandler {
+	return map[ast.NodeKind]BlockHandler{
+		ast.KindHeading: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertHeading(node.(*ast.Heading), source))
+		},
+		ast.KindParagraph: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertParagraph(node.(*ast.Paragraph), source))
+		},
+		ast.KindList: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return c.convertList(node.(*ast.List), source)
+		},
+		ast.KindBlockquote: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertBlockquote(node.(*ast.Blockquote), source))
+		},
+		ast.KindCodeBlock: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertCodeBlock(node.(*ast.CodeBlock), source))
+		},
+		ast.KindFencedCodeBlock: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			fenced := node.(*ast.FencedCodeBlock)
+			if blocks, ok := c.convertRawBlocks(fenced, source); ok {
+				return blocks, nil
+			}
+			return single(c.convertFencedCodeBlock(fenced, source))
+		},
+		ast.KindThematicBreak: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertThematicBreak())
+		},
+		ast.KindHTMLBlock: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			// Skip HTML blocks for simplicity
+			c.report(node, source, SeverityWarning, KindHTMLDropped, "HTML block dropped")
+			return nil, nil
+		},
+		extast.KindTable: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertTable(node.(*extast.Table), source))
+		},
+		extast.KindDefinitionList: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return c.convertDefinitionList(node.(*extast.DefinitionList), source)
+		},
+		KindAdmonition: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertAdmonition(node.(*Admonition), source))
+		},
+		KindContentTab: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return single(c.convertContentTab(node.(*ContentTab), source))
+		},
+		KindCommentBlock: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			// Comments are hidden from readers
+			return nil, nil
+		},
+		extast.KindFootnoteList: func(c *Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+			return c.convertFootnoteList(node.(*extast.FootnoteList), source)
+		},
+	}
+}
+
+// builtinInlineHandlers returns the conversions of the inline nodes produced
+// by the standard parser and the built-in extensions
+func builtinInlineHandlers() map[ast.NodeKind]InlineHandler {
+	return map[ast.NodeKind]InlineHandler{
+		ast.KindString: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			return plainSpans(string(node.(*ast.String).Value)), nil
+		},
+		ast.KindText: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			return plainSpans(string(node.(*ast.Text).Segment.Value(source))), nil
+		},
+		ast.KindCodeSpan: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			return []document.Span{{
+				Text:  string(node.Text(source)),
+				Marks: document.Marks{Code: true},
+			}}, nil
+		},
+		ast.KindEmphasis: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			spans, err := c.convertInlineNodes(node, source)
+			if err != nil {
+				return nil, err
+			}
+			// Apply formatting based on emphasis level (1=italic, 2=bold)
+			level := node.(*ast.Emphasis).Level
+			for i := range spans {
+				if level == 2 {
+					spans[i].Marks.Bold = true
+				} else {
+					spans[i].Marks.Italic = true
+				}
+			}
+			return spans, nil
+		},
+		ast.KindLink: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			spans, err := c.convertInlineNodes(node, source)
+			if err != nil {
+				return nil, err
+			}
+			// Apply link
+			href := c.resolveLink(string(node.(*ast.Link).Destination))
+			for i := range spans {
+				spans[i].Href = href
+			}
+			return spans, nil
+		},
+		ast.KindAutoLink: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			href := string(node.(*ast.AutoLink).URL(source))
+			return []document.Span{{Text: href, Href: href}}, nil
+		},
+		ast.KindImage: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			// Images in inline context are skipped (handled at paragraph level)
+			c.report(node, source, SeverityWarning, KindImageSkipped,
+				fmt.Sprintf("inline image %s dropped, only images on their own line are kept", node.(*ast.Image).Destination))
+			return nil, nil
+		},
+		ast.KindRawHTML: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			c.report(node, source, SeverityWarning, KindHTMLDropped, "inline HTML dropped")
+			return nil, nil
+		},
+		extast.KindFootnoteLink: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			return []document.Span{c.convertFootnoteLink(node.(*extast.FootnoteLink))}, nil
+		},
+		extast.KindStrikethrough: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			return c.convertStrikethrough(node.(*extast.Strikethrough), source)
+		},
+		extast.KindTaskCheckBox: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			// The checkbox makes its list item a to-do
+			return nil, nil
+		},
+		KindTag: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			return c.convertTag(node.(*Tag)), nil
+		},
+		KindComment: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			return nil, nil
+		},
+		extast.KindFootnoteBacklink: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			// Backlinks only make sense in HTML output
+			return nil, nil
+		},
+		emojiast.KindEmoji: func(c *Converter, node ast.Node, source []byte) ([]document.Span, error) {
+			// Shortcodes without a Unicode form are kept as typed
+			n := node.(*emojiast.Emoji)
+			content := ":" + string(n.ShortName) + ":"
+			if n.Value != nil && n.Value.IsUnicode() {
+				content = string(n.Value.Unicode)
+			}
+			return plainSpans(content), nil
+		},
+	}
+}
+
+// single wraps the result of a converter producing at most one block
+func single(block *document.Block, err error) ([]*document.Block, error) {
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return []*document.Block{block}, nil
+}
+
+// plainSpans returns unformatted text as spans
+func plainSpans(content string) []document.Span {
+	return []document.Span{document.Text(content)}
+}
@@ -0,0 +1,166 @@
+// internal/markdown/images.go
+package markdown
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// ImageUploader publishes local and embedded images to an image host.
+// Upload returns the public URL of the image, or an empty URL when the
+// image should be left out of the page. ctx is the context of the
+// conversion, cancelling the upload when it is done.
+type ImageUploader interface {
+	Upload(ctx context.Context, file notion.FileData) (string, error)
+}
+
+// dedupUploader remembers the URL of every image content it has uploaded
+type dedupUploader struct {
+	uploader ImageUploader
+
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+// Upload uploads file unless an image with the same content was already uploaded
+func (d *dedupUploader) Upload(ctx context.Context, file notion.FileData) (string, error) {
+	sum := sha256.Sum256(file.Data)
+	hash := hex.EncodeToString(sum[:])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if u, ok := d.urls[hash]; ok {
+		return u, nil
+	}
+
+	u, err := d.uploader.Upload(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	d.urls[hash] = u
+	return u, nil
+}
+
+// loadImage returns the content of an embedded data URI image or of a local
+// image file, resolved relative to the Markdown file. It returns nil for
+// remote images and for relative images when an image base URL is set, and
+// an error when an embedded or local image cannot be read.
+func (c *Converter) loadImage(src string) (*notion.FileData, error) {
+	if strings.HasPrefix(src, "data:") {
+		file, err := decodeDataURI(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data URI image: %w", err)
+		}
+		return file, nil
+	}
+
+	if src == "" || c.imageBaseURL != "" || c.isAbsoluteURL(src) {
+		return nil, nil
+	}
+
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return nil, nil
+	}
+
+	filePath := filepath.Join(c.sourceDir, filepath.FromSlash(u.Path))
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read local image %s: %w", src, err)
+	}
+
+	return &notion.FileData{
+		Name:        filepath.Base(filePath),
+		ContentType: detectContentType(filePath, data),
+		Data:        data,
+	}, nil
+}
+
+// decodeDataURI decodes a "data:[<mediatype>][;base64],<data>" URI
+func decodeDataURI(uri string) (*notion.FileData, error) {
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("missing data separator")
+	}
+
+	isBase64 := strings.HasSuffix(meta, ";base64")
+	mediaType := strings.TrimSuffix(meta, ";base64")
+	if mt, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = mt
+	}
+
+	var data []byte
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(payload), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 data: %w", err)
+		}
+		data = decoded
+	} else {
+		decoded, err := url.PathUnescape(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+		data = []byte(decoded)
+	}
+
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return nil, fmt.Errorf("unsupported media type %q", mediaType)
+	}
+
+	return &notion.FileData{
+		Name:        "image" + imageExtension(mediaType),
+		ContentType: mediaType,
+		Data:        data,
+	}, nil
+}
+
+// detectContentType guesses the MIME type of a file from its name, then its content
+func detectContentType(name string, data []byte) string {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		if mt, _, err := mime.ParseMediaType(ct); err == nil {
+			return mt
+		}
+	}
+	ct := http.DetectContentType(data)
+	if mt, _, err := mime.ParseMediaType(ct); err == nil {
+		return mt
+	}
+	return ct
+}
+
+// imageExtension returns the usual file extension for an image MIME type
+func imageExtension(mediaType string) string {
+	switch mediaType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/svg+xml":
+		return ".svg"
+	case "image/webp":
+		return ".webp"
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// pngHeader is enough of a PNG file for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestConverter_LocalImages(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "img"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "img", "shot one.png"), pngHeader, 0600); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(dir, "doc.md")
+
+	tests := []struct {
+		name     string
+		markdown string
+		baseURL  string
+		wantType string
+		wantName string
+		wantMIME string
+	}{
+		{
+			name:     "local file next to the document",
+			markdown: "![Screenshot](img/shot%20one.png)",
+			wantType: "file_upload",
+			wantName: "shot one.png",
+			wantMIME: "image/png",
+		},
+		{
+			name:     "base64 data URI",
+			markdown: "![Dot](data:image/gif;base64,R0lGODlhAQABAAAAACw=)",
+			wantType: "file_upload",
+			wantName: "image.gif",
+			wantMIME: "image/gif",
+		},
+		{
+			name:     "base URL takes precedence over local files",
+			markdown: "![Screenshot](img/shot%20one.png)",
+			baseURL:  "https://example.com/",
+			wantType: "external",
+		},
+		{
+			name:     "missing local file is skipped",
+			markdown: "![Missing](img/missing.png)",
+		},
+		{
+			name:     "non-image data URI is skipped",
+			markdown: "![Text](data:text/plain,hello)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter(tt.baseURL, false, WithSourceFile(source))
+			blocks, _, err := c.Convert([]byte(tt.markdown))
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+
+			if tt.wantType == "" {
+				if len(blocks) != 0 {
+					t.Errorf("Convert() returned %d blocks, want none", len(blocks))
+				}
+				return
+			}
+			if len(blocks) != 1 || blocks[0].Image == nil {
+				t.Fatalf("Convert() = %+v, want one image block", blocks)
+			}
+
+			image := blocks[0].Image
+			if image.Type != tt.wantType {
+				t.Errorf("Image.Type = %q, want %q", image.Type, tt.wantType)
+			}
+			if tt.wantType != "file_upload" {
+				return
+			}
+			if image.Pending == nil {
+				t.Fatalf("file upload image has no pending file: %+v", image)
+			}
+			if image.Pending.Name != tt.wantName || image.Pending.ContentType != tt.wantMIME {
+				t.Errorf("pending file = %q (%s), want %q (%s)",
+					image.Pending.Name, image.Pending.ContentType, tt.wantName, tt.wantMIME)
+			}
+		})
+	}
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	file, err := decodeDataURI("data:image/svg+xml,%3Csvg%2F%3E")
+	if err != nil {
+		t.Fatalf("decodeDataURI() error = %v", err)
+	}
+	if !bytes.Equal(file.Data, []byte("<svg/>")) || file.Name != "image.svg" {
+		t.Errorf("decodeDataURI() = %q (%s)", file.Data, file.Name)
+	}
+
+	if _, err := decodeDataURI("data:image/png;base64"); err == nil {
+		t.Error("decodeDataURI() without data should fail")
+	}
+	if _, err := decodeDataURI("data:image/png;base64,%%%"); err == nil {
+		t.Error("decodeDataURI() with invalid base64 should fail")
+	}
+}
+
+// countingUploader records uploads and hosts images under a fixed URL
+type countingUploader struct {
+	calls int
+	url   string
+}
+
+func (u *countingUploader) Upload(ctx context.Context, file notion.FileData) (string, error) {
+	u.calls++
+	return u.url, nil
+}
+
+func TestConverter_ImageUploader(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "copy-of-a.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), pngHeader, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	markdown := "![A](a.png)\n\n![Same](copy-of-a.png)\n\n![Remote](https://example.com/b.png)"
+
+	uploader := &countingUploader{url: "https://cdn.example.com/a.png"}
+	c := NewConverter("", false, WithSourceFile(filepath.Join(dir, "doc.md")), WithImageUploader(uploader))
+	blocks, _, err := c.Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("Convert() returned %d blocks, want 3", len(blocks))
+	}
+	for i, want := range []string{"https://cdn.example.com/a.png", "https://cdn.example.com/a.png", "https://example.com/b.png"} {
+		image := blocks[i].Image
+		if image.Type != "external" || image.External.URL != want {
+			t.Errorf("block %d image = %s %+v, want external %q", i, image.Type, image.External, want)
+		}
+	}
+	if uploader.calls != 1 {
+		t.Errorf("uploader called %d times, want once for identical content", uploader.calls)
+	}
+
+	// An empty URL drops the image
+	c = NewConverter("", false, WithSourceFile(filepath.Join(dir, "doc.md")), WithImageUploader(&countingUploader{}))
+	blocks, _, err = c.Convert([]byte("![A](a.png)"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 0 {
+		t.Errorf("Convert() returned %d blocks, want none", len(blocks))
+	}
+}
+
+func TestConverter_ImageCaptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		opts     []Option
+		want     []notion.RichText
+	}{
+		{
+			name:     "formatted alt text",
+			markdown: "![The **new** `api` flow](https://example.com/a.png)",
+			want: []notion.RichText{
+				{Type: "text", Text: &notion.Text{Content: "The "}},
+				{Type: "text", Text: &notion.Text{Content: "new"}, Annotations: &notion.Annotations{Bold: true}},
+				{Type: "text", Text: &notion.Text{Content: " "}},
+				{Type: "text", Text: &notion.Text{Content: "api"}, Annotations: &notion.Annotations{Code: true}},
+				{Type: "text", Text: &notion.Text{Content: " flow"}},
+			},
+		},
+		{
+			name:     "title preferred over alt text",
+			markdown: `![Alt](https://example.com/a.png "Figure 1: overview")`,
+			want:     []notion.RichText{{Type: "text", Text: &notion.Text{Content: "Figure 1: overview"}}},
+		},
+		{
+			name:     "captions disabled",
+			markdown: `![Alt](https://example.com/a.png "Title")`,
+			opts:     []Option{WithImageCaptions(false)},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("", false, tt.opts...)
+			blocks, _, err := c.Convert([]byte(tt.markdown))
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if len(blocks) != 1 || blocks[0].Image == nil {
+				t.Fatalf("Convert() = %+v, want one image block", blocks)
+			}
+			compareRichText(t, blocks[0].Image.Caption, tt.want)
+		})
+	}
+}
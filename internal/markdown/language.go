@@ -0,0 +1,304 @@
+// internal/markdown/language.go
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"gopkg.in/yaml.v3"
+)
+
+// WithLanguageDetection guesses the language of code blocks that have none,
+// indented code and fences without an info string, instead of tagging them
+// as plain text. Guesses are reported as language_detected diagnostics.
+func WithLanguageDetection(enabled bool) Option {
+	return func(c *Converter) {
+		c.detectLanguage = enabled
+	}
+}
+
+// detectCodeLanguage returns the guessed language of an unlabeled code
+// block, or "plain text" when detection is off or nothing matches
+func (c *Converter) detectCodeLanguage(node ast.Node, source []byte, code string) string {
+	if !c.detectLanguage {
+		return "plain text"
+	}
+	language, reason := detectLanguage(code, fileNameHint(node, source))
+	if language == "" {
+		return "plain text"
+	}
+	c.report(node, source, SeverityInfo, KindLanguageDetected,
+		fmt.Sprintf("code language detected as %q from %s", language, reason))
+	return language
+}
+
+// detectLanguage guesses the language of code offline. hint is a file name
+// mentioned next to the code, if any. It returns the language and what it
+// was guessed from, or "" when the code matches no language clearly.
+func detectLanguage(code, hint string) (string, string) {
+	trimmed := strings.TrimSpace(code)
+	if trimmed == "" {
+		return "", ""
+	}
+
+	firstLine, _, _ := strings.Cut(trimmed, "\n")
+	if language := shebangLanguage(firstLine); language != "" {
+		return language, "its shebang"
+	}
+	if name := commentFileName(firstLine); name != "" {
+		if language := fileLanguage(name); language != "" {
+			return language, "the file name " + name
+		}
+	}
+	if language := fileLanguage(hint); language != "" {
+		return language, "the file name " + hint
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return "json", "its JSON syntax"
+	}
+	if language := keywordLanguage(code); language != "" {
+		return language, "its keywords"
+	}
+	if looksLikeYAML(trimmed) {
+		return "yaml", "its YAML syntax"
+	}
+	return "", ""
+}
+
+// shebangInterpreters maps the interpreters of shebang lines to languages
+var shebangInterpreters = map[string]string{
+	"sh":      "bash",
+	"bash":    "bash",
+	"zsh":     "bash",
+	"ksh":     "bash",
+	"dash":    "bash",
+	"python":  "python",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"ts-node": "typescript",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"lua":     "lua",
+	"pwsh":    "powershell",
+}
+
+// shebangLanguage returns the language of a "#!/usr/bin/env python3" line
+func shebangLanguage(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env options such as -S
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	// Drop versions, as in python3 or python3.12
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return shebangInterpreters[interpreter]
+}
+
+var (
+	// fileNamePattern matches file names such as config.yaml, cmd/main.go
+	// or Dockerfile in text
+	fileNamePattern = regexp.MustCompile(`(?:[\w.-]+/)*(?:[\w-]+(?:\.[\w-]+)*\.[A-Za-z]+\b|Dockerfile\b|Makefile\b)`)
+	// fileComment matches a first line naming its file, as in
+	// "// cmd/main.go" or "# config.yaml"
+	fileComment = regexp.MustCompile(`^(?://|#|--|;|<!--|/\*)\s*((?:[\w.-]+/)*[\w.-]+)\s*(?:-->|\*/)?$`)
+)
+
+// fileExtensions maps file extensions to code languages
+var fileExtensions = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".mjs": "javascript",
+	".cjs": "javascript", ".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript",
+	".sh": "bash", ".bash": "bash", ".zsh": "bash", ".yml": "yaml", ".yaml": "yaml",
+	".json": "json", ".xml": "xml", ".html": "html", ".htm": "html", ".css": "css",
+	".scss": "scss", ".sass": "sass", ".less": "less", ".sql": "sql", ".rb": "ruby",
+	".rs": "rust", ".java": "java", ".kt": "kotlin", ".kts": "kotlin", ".c": "c",
+	".h": "c", ".cpp": "c++", ".cc": "c++", ".hpp": "c++", ".cs": "c#", ".php": "php",
+	".swift": "swift", ".scala": "scala", ".lua": "lua", ".pl": "perl", ".r": "r",
+	".ex": "elixir", ".exs": "elixir", ".erl": "erlang", ".hs": "haskell", ".dart": "dart",
+	".proto": "protobuf", ".graphql": "graphql", ".gql": "graphql", ".ps1": "powershell",
+	".md": "markdown", ".diff": "diff", ".patch": "diff", ".nix": "nix", ".tex": "latex",
+	".mk": "makefile",
+}
+
+// fileLanguage returns the language of a file name, or ""
+func fileLanguage(name string) string {
+	if name == "" {
+		return ""
+	}
+	base := path.Base(name)
+	switch {
+	case base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile."):
+		return "docker"
+	case base == "Makefile" || base == "GNUmakefile":
+		return "makefile"
+	}
+	return fileExtensions[strings.ToLower(path.Ext(base))]
+}
+
+// commentFileName returns the file name a first-line comment names, or ""
+func commentFileName(line string) string {
+	m := fileComment.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil || fileLanguage(m[1]) == "" {
+		return ""
+	}
+	return m[1]
+}
+
+// fileNameHint returns the last file name with a known language mentioned
+// in the paragraph or heading right before a code block, as in
+// "Create config.yaml:"
+func fileNameHint(node ast.Node, source []byte) string {
+	previous := node.PreviousSibling()
+	if previous == nil || (previous.Kind() != ast.KindParagraph && previous.Kind() != ast.KindHeading) {
+		return ""
+	}
+	var text strings.Builder
+	lines := previous.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		text.Write(line.Value(source))
+	}
+
+	hint := ""
+	for _, name := range fileNamePattern.FindAllString(text.String(), -1) {
+		if fileLanguage(name) != "" {
+			hint = name
+		}
+	}
+	return hint
+}
+
+// languageRule is a pattern hinting at a language, with its weight
+type languageRule struct {
+	language string
+	pattern  *regexp.Regexp
+	weight   int
+}
+
+// languageRules are the signals keywordLanguage scores code with
+var languageRules = []languageRule{
+	{"go", regexp.MustCompile(`(?m)^package \w+$`), 3},
+	{"go", regexp.MustCompile(`(?m)^func (\(\w+ \*?\w+\) )?\w+\(`), 3},
+	{"go", regexp.MustCompile(`\w+ := `), 1},
+	{"go", regexp.MustCompile(`\b(fmt|errors|strings)\.\w+\(`), 2},
+	{"go", regexp.MustCompile(`\bif err != nil \{`), 3},
+
+	{"python", regexp.MustCompile(`(?m)^\s*def \w+\(.*\)( -> [\w\[\], .]+)?:\s*$`), 3},
+	{"python", regexp.MustCompile(`(?m)^(from [\w.]+ )?import [\w., ]+$`), 1},
+	{"python", regexp.MustCompile(`(?m)^\s*(elif .*|else|try|except( \w+)?( as \w+)?|finally):\s*$`), 2},
+	{"python", regexp.MustCompile(`\bself\.\w+`), 1},
+	{"python", regexp.MustCompile(`(?m)^\s*class \w+(\(.*\))?:\s*$`), 3},
+	{"python", regexp.MustCompile(`\bprint\(`), 1},
+
+	{"javascript", regexp.MustCompile(`(?m)^\s*(const|let|var) \w+ = `), 2},
+	{"javascript", regexp.MustCompile(`\bfunction\s*\w*\s*\(`), 2},
+	{"javascript", regexp.MustCompile(`\) => \{?`), 2},
+	{"javascript", regexp.MustCompile(`\bconsole\.log\(`), 3},
+	{"javascript", regexp.MustCompile(`\brequire\(['"]`), 3},
+	{"javascript", regexp.MustCompile(`(?m)^(import .* from ['"]|export (default|const|function) )`), 2},
+
+	{"typescript", regexp.MustCompile(`(?m)^\s*(export )?(interface|type) \w+(<.*>)? (=|\{)`), 3},
+	{"typescript", regexp.MustCompile(`\w\??: (string|number|boolean|void|any|unknown)\b`), 3},
+
+	{"bash", regexp.MustCompile(`(?m)^\$ \S`), 3},
+	{"bash", regexp.MustCompile(`(?m)^(sudo|apt-get|apt|brew|yum|npm|npx|yarn|pip3?|go|git|docker|kubectl|helm|curl|wget|make|cd|ls|mkdir|rm|cp|mv|chmod|export|source|echo|cat) `), 2},
+	{"bash", regexp.MustCompile(`(?m)^\s*(fi|done|esac)\s*$`), 3},
+	{"bash", regexp.MustCompile(`(?m)^\s*if \[\[? .* \]\]?; then`), 3},
+	{"bash", regexp.MustCompile(` \\$`), 1},
+
+	{"sql", regexp.MustCompile(`(?im)^\s*(SELECT\s.+\sFROM|INSERT INTO|UPDATE \w+ SET|DELETE FROM|CREATE (TABLE|INDEX|VIEW)|ALTER TABLE|DROP TABLE)\b`), 4},
+	{"sql", regexp.MustCompile(`(?m)\b(WHERE|GROUP BY|ORDER BY|JOIN)\b`), 1},
+
+	{"html", regexp.MustCompile(`(?i)<!DOCTYPE html|<(html|head|body|div|span|ul|li|script|a href)\b`), 3},
+	{"xml", regexp.MustCompile(`^<\?xml `), 5},
+
+	{"css", regexp.MustCompile(`(?m)^[.#]?[\w-]+( [.#]?[\w-]+)*\s*\{\s*$`), 1},
+	{"css", regexp.MustCompile(`(?m)^\s+[a-z-]+:\s*[^;{}]+;\s*$`), 2},
+
+	{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+(<.*>)?\(`), 3},
+	{"rust", regexp.MustCompile(`\blet mut \w+`), 3},
+	{"rust", regexp.MustCompile(`\b(println|vec|format)!\(`), 3},
+	{"rust", regexp.MustCompile(`(?m)^\s*(use \w+::|impl\b)`), 2},
+
+	{"java", regexp.MustCompile(`\bpublic (static )?(final )?(class|void|interface)\b`), 3},
+	{"java", regexp.MustCompile(`\bSystem\.out\.print`), 3},
+
+	{"ruby", regexp.MustCompile(`(?m)^\s*def \w+[?!]?(\(.*\))?\s*$`), 2},
+	{"ruby", regexp.MustCompile(`(?m)^\s*end\s*$`), 2},
+	{"ruby", regexp.MustCompile(`(?m)^\s*(puts|require) `), 2},
+
+	{"docker", regexp.MustCompile(`(?m)^FROM \S+( AS \w+)?$`), 3},
+	{"docker", regexp.MustCompile(`(?m)^(RUN|COPY|WORKDIR|ENTRYPOINT|CMD|EXPOSE|ENV) `), 2},
+
+	{"diff", regexp.MustCompile(`(?m)^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`), 5},
+	{"diff", regexp.MustCompile(`(?m)^(---|\+\+\+) \S`), 2},
+}
+
+// minKeywordScore is the score a language must reach for a guess
+const minKeywordScore = 3
+
+// keywordLanguage scores code against languageRules and returns the best
+// scoring language, or "" when none stands out
+func keywordLanguage(code string) string {
+	scores := make(map[string]int)
+	for _, rule := range languageRules {
+		if rule.pattern.MatchString(code) {
+			scores[rule.language] += rule.weight
+		}
+	}
+	// Type annotations make JavaScript TypeScript
+	if scores["typescript"] > 0 {
+		scores["typescript"] += scores["javascript"]
+	}
+
+	best, bestScore, tied := "", 0, false
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = language, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+	if bestScore < minKeywordScore || tied {
+		return ""
+	}
+	return best
+}
+
+// yamlKey matches a line starting a YAML mapping entry
+var yamlKey = regexp.MustCompile(`(?m)^\s*(- )?[\w.-]+:( |$)`)
+
+// looksLikeYAML reports whether code parses as a YAML mapping or sequence
+// of mappings, rather than as a lone scalar like most prose does
+func looksLikeYAML(code string) bool {
+	if len(yamlKey.FindAllString(code, 2)) < 2 {
+		return false
+	}
+	var value any
+	if err := yaml.Unmarshal([]byte(code), &value); err != nil {
+		return false
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// internal/markdown/language_test.go
+package markdown
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		hint string
+		want string
+	}{
+		{"shebang env", "#!/usr/bin/env python3\nprint('hi')\n", "", "python"},
+		{"shebang path", "#!/bin/bash\nset -e\n", "", "bash"},
+		{"file comment", "// cmd/main.go\nx = 1\n", "", "go"},
+		{"file name hint", "listen: 8080\n", "config.yaml", "yaml"},
+		{"dockerfile hint", "something\n", "Dockerfile", "docker"},
+		{"json", "{\"name\": \"billing\", \"replicas\": 3}\n", "", "json"},
+		{"go", "package main\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n", "", "go"},
+		{"python", "def greet(name):\n    print(name)\n", "", "python"},
+		{"typescript", "interface User {\n  name: string;\n}\nconst u: User = load();\n", "", "typescript"},
+		{"javascript", "const fs = require('fs');\nconsole.log(fs);\n", "", "javascript"},
+		{"shell session", "$ go build ./...\n$ ./notion-md --help\n", "", "bash"},
+		{"sql", "SELECT id, name FROM users WHERE active;\n", "", "sql"},
+		{"yaml", "service: billing\nreplicas: 3\nports:\n  - 8080\n", "", "yaml"},
+		{"dockerfile", "FROM golang:1.25 AS build\nRUN go build ./...\n", "", "docker"},
+		{"diff", "--- a/x.go\n+++ b/x.go\n@@ -1,2 +1,2 @@\n-a\n+b\n", "", "diff"},
+		{"prose", "Hello world, this is not code.\n", "", ""},
+		{"empty", "\n", "", ""},
+		{"unknown hint", "whatever\n", "notes.txt", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := detectLanguage(tt.code, tt.hint)
+			if got != tt.want {
+				t.Errorf("detectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverter_LanguageDetection(t *testing.T) {
+	markdown := "Create `deploy/values.yaml`:\n\n```\nimage: billing\ntag: latest\n```\n\n" +
+		"Then run:\n\n    $ helm upgrade billing ./chart\n\n```\nJust some words.\n```\n"
+
+	blocks, diagnostics, err := NewConverter("", false, WithLanguageDetection(true)).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	var languages []string
+	for _, b := range blocks {
+		if b.Code != nil {
+			languages = append(languages, b.Code.Language)
+		}
+	}
+	want := []string{"yaml", "bash", "plain text"}
+	if len(languages) != len(want) {
+		t.Fatalf("got languages %v, want %v", languages, want)
+	}
+	for i := range want {
+		if languages[i] != want[i] {
+			t.Errorf("code block %d language = %q, want %q", i, languages[i], want[i])
+		}
+	}
+
+	if len(diagnostics) != 2 {
+		t.Fatalf("got diagnostics %v, want 2", diagnostics)
+	}
+	d := diagnostics[0]
+	if d.Kind != KindLanguageDetected || d.Severity != SeverityInfo || d.Line != 4 || d.Lossy() {
+		t.Errorf("got diagnostic %+v", d)
+	}
+	if d.Message != `code language detected as "yaml" from the file name deploy/values.yaml` {
+		t.Errorf("got message %q", d.Message)
+	}
+
+	// Without the option unlabeled code stays plain text
+	blocks, diagnostics, err = NewConverter("", false).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	for _, b := range blocks {
+		if b.Code != nil && b.Code.Language != "plain text" {
+			t.Errorf("language = %q without detection", b.Code.Language)
+		}
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("got diagnostics %v without detection", diagnostics)
+	}
+}
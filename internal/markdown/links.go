@@ -0,0 +1,114 @@
+// internal/markdown/links.go
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LinkMap maps Markdown files to the Notion pages or URLs they are published at.
+// Keys are absolute, cleaned file paths.
+type LinkMap map[string]string
+
+// NewLinkMap builds a LinkMap from entries whose keys are paths relative to baseDir
+// and whose values are Notion page IDs or URLs
+func NewLinkMap(baseDir string, entries map[string]string) (LinkMap, error) {
+	links := make(LinkMap, len(entries))
+	for key, target := range entries {
+		abs, err := filepath.Abs(filepath.Join(baseDir, filepath.FromSlash(key)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid link map path %q: %w", key, err)
+		}
+		target = strings.TrimSpace(target)
+		if target == "" {
+			return nil, fmt.Errorf("link map entry %q has no target", key)
+		}
+		links[abs] = target
+	}
+	return links, nil
+}
+
+// LoadLinkMap reads a JSON object mapping relative paths to Notion page IDs or URLs.
+// Paths are relative to the directory of the mapping file.
+func LoadLinkMap(path string) (LinkMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse link map %s: %w", path, err)
+	}
+
+	return NewLinkMap(filepath.Dir(path), entries)
+}
+
+// resolveLink rewrites a relative link destination: links to mapped documents
+// point at their Notion pages, other relative links are joined with the link
+// base URL. Absolute URLs and intra-page anchors are returned unchanged.
+func (c *Converter) resolveLink(href string) string {
+	if href == "" || strings.HasPrefix(href, "#") || c.isAbsoluteURL(href) {
+		return href
+	}
+
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return href
+	}
+
+	if target, ok := c.lookupLink(u.Path); ok {
+		if isNotionPageID(target) {
+			target = "https://notion.so/" + strings.ReplaceAll(target, "-", "")
+		}
+		if u.Fragment != "" && !strings.Contains(target, "#") {
+			target += "#" + u.Fragment
+		}
+		return target
+	}
+
+	if c.linkBaseURL != "" {
+		resolved := c.joinURL(c.linkBaseURL, u.Path)
+		if u.RawQuery != "" {
+			resolved += "?" + u.RawQuery
+		}
+		if u.Fragment != "" {
+			resolved += "#" + u.Fragment
+		}
+		return resolved
+	}
+
+	return href
+}
+
+// lookupLink finds the mapped target of a path relative to the source document
+func (c *Converter) lookupLink(relPath string) (string, bool) {
+	if len(c.linkMap) == 0 || strings.HasPrefix(relPath, "/") {
+		return "", false
+	}
+
+	abs, err := filepath.Abs(filepath.Join(c.sourceDir, filepath.FromSlash(relPath)))
+	if err != nil {
+		return "", false
+	}
+	target, ok := c.linkMap[abs]
+	return target, ok
+}
+
+// isNotionPageID reports whether s is a bare Notion page ID, with or without dashes
+func isNotionPageID(s string) bool {
+	cleaned := strings.ReplaceAll(s, "-", "")
+	if len(cleaned) != 32 {
+		return false
+	}
+	for _, r := range strings.ToLower(cleaned) {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
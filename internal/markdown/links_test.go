@@ -0,0 +1,84 @@
+package markdown
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConverter_resolveLink(t *testing.T) {
+	root := t.TempDir()
+	mapFile := filepath.Join(root, "links.json")
+	content := `{"docs/api.md": "0123456789abcdef0123456789abcdef", "docs/faq.md": "https://wiki.example.com/faq"}`
+	if err := os.WriteFile(mapFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	links, err := LoadLinkMap(mapFile)
+	if err != nil {
+		t.Fatalf("LoadLinkMap() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		href    string
+		want    string
+	}{
+		{"mapped page ID", "", "./api.md", "https://notion.so/0123456789abcdef0123456789abcdef"},
+		{"mapped page ID keeps fragment", "", "api.md#auth", "https://notion.so/0123456789abcdef0123456789abcdef#auth"},
+		{"mapped URL keeps fragment", "", "faq.md#billing", "https://wiki.example.com/faq#billing"},
+		{"mapped from parent directory", "", "../docs/api.md", "https://notion.so/0123456789abcdef0123456789abcdef"},
+		{"unmapped without base URL", "", "setup.md", "setup.md"},
+		{"unmapped with base URL", "https://git.example.com/repo/blob/main/docs", "setup.md#step-2", "https://git.example.com/repo/blob/main/docs/setup.md#step-2"},
+		{"unmapped with query", "https://git.example.com/docs/", "../scripts/run.sh?plain=1", "https://git.example.com/scripts/run.sh?plain=1"},
+		{"absolute URL", "https://git.example.com/", "https://example.com/a.md", "https://example.com/a.md"},
+		{"anchor", "https://git.example.com/", "#usage", "#usage"},
+		{"mailto", "https://git.example.com/", "mailto:docs@example.com", "mailto:docs@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("", false,
+				WithSourceFile(filepath.Join(root, "docs", "guide.md")),
+				WithLinkMap(links),
+				WithLinkBaseURL(tt.baseURL),
+			)
+			if got := c.resolveLink(tt.href); got != tt.want {
+				t.Errorf("resolveLink(%q) = %q, want %q", tt.href, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverter_ConvertRewritesLinks(t *testing.T) {
+	c := NewConverter("", false, WithLinkBaseURL("https://git.example.com/docs/"))
+	blocks, _, err := c.Convert([]byte("See [the API](./api.md) and [usage](#usage)."))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	richText := blocks[0].Paragraph.RichText
+	if got := *richText[1].Href; got != "https://git.example.com/docs/api.md" {
+		t.Errorf("relative link href = %q", got)
+	}
+	if got := *richText[3].Href; got != "#usage" {
+		t.Errorf("anchor link href = %q", got)
+	}
+}
+
+func TestLoadLinkMap_InvalidEntries(t *testing.T) {
+	mapFile := filepath.Join(t.TempDir(), "links.json")
+	if err := os.WriteFile(mapFile, []byte(`{"api.md": " "}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLinkMap(mapFile); err == nil {
+		t.Error("LoadLinkMap() with an empty target should fail")
+	}
+
+	if err := os.WriteFile(mapFile, []byte(`["api.md"]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLinkMap(mapFile); err == nil {
+		t.Error("LoadLinkMap() with a JSON array should fail")
+	}
+}
@@ -0,0 +1,270 @@
+// internal/markdown/mkdocs.go
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// mkdocs parses the syntax of MkDocs Material sites
+type mkdocs struct{}
+
+// Extend adds the MkDocs parsers to a Markdown parser
+func (mkdocs) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(
+		util.Prioritized(admonitionParser{}, 50),
+		util.Prioritized(contentTabParser{}, 50),
+	))
+}
+
+// contentIndent is the indentation of the content of admonitions and tabs
+const contentIndent = 4
+
+// KindAdmonition is the node kind of MkDocs admonitions
+var KindAdmonition = ast.NewNodeKind("Admonition")
+
+// Admonition is an MkDocs admonition: a "!!! type" line followed by its
+// indented content, or a collapsible one opened with "???"
+type Admonition struct {
+	ast.BaseBlock
+	// Qualifier is the type of admonition, such as note or warning
+	Qualifier string
+	// Title is the quoted title, nil when there is none
+	Title *string
+	// Collapsible is set for "???" admonitions
+	Collapsible bool
+}
+
+// Kind implements ast.Node
+func (n *Admonition) Kind() ast.NodeKind {
+	return KindAdmonition
+}
+
+// Dump implements ast.Node
+func (n *Admonition) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Qualifier": n.Qualifier}, nil)
+}
+
+// admonitionLine matches `!!! type "Title"`, with optional modifiers such as
+// "inline end" before the title
+var admonitionLine = regexp.MustCompile(`^(!!!|\?\?\?\+?)[ \t]+([\w-]+)(?:[ \t]+[\w-]+)*(?:[ \t]+"([^"]*)")?[ \t]*$`)
+
+// admonitionParser parses admonitions
+type admonitionParser struct{}
+
+func (admonitionParser) Trigger() []byte {
+	return []byte{'!', '?'}
+}
+
+func (admonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 {
+		return nil, parser.NoChildren
+	}
+	m := admonitionLine.FindSubmatchIndex(util.TrimRightSpace(line[pos:]))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	rest := line[pos:]
+	node := &Admonition{
+		Qualifier:   strings.ToLower(string(rest[m[4]:m[5]])),
+		Collapsible: rest[0] == '?',
+	}
+	if m[6] >= 0 {
+		title := string(rest[m[6]:m[7]])
+		node.Title = &title
+	}
+	reader.Advance(segment.Len() - 1)
+	return node, parser.HasChildren
+}
+
+func (admonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return continueIndented(reader)
+}
+
+func (admonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (admonitionParser) CanInterruptParagraph() bool {
+	return false
+}
+
+func (admonitionParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// KindContentTab is the node kind of MkDocs content tabs
+var KindContentTab = ast.NewNodeKind("ContentTab")
+
+// ContentTab is an MkDocs content tab: a `=== "Label"` line followed by
+// its indented content
+type ContentTab struct {
+	ast.BaseBlock
+	Label string
+}
+
+// Kind implements ast.Node
+func (n *ContentTab) Kind() ast.NodeKind {
+	return KindContentTab
+}
+
+// Dump implements ast.Node
+func (n *ContentTab) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Label": n.Label}, nil)
+}
+
+// contentTabLine matches `=== "Label"`, "===+" selecting the tab and "===!"
+// starting a new group of tabs
+var contentTabLine = regexp.MustCompile(`^===[+!]?[ \t]+"([^"]*)"[ \t]*$`)
+
+// contentTabParser parses content tabs
+type contentTabParser struct{}
+
+func (contentTabParser) Trigger() []byte {
+	return []byte{'='}
+}
+
+func (contentTabParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 {
+		return nil, parser.NoChildren
+	}
+	m := contentTabLine.FindSubmatch(util.TrimRightSpace(line[pos:]))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	reader.Advance(segment.Len() - 1)
+	return &ContentTab{Label: string(m[1])}, parser.HasChildren
+}
+
+func (contentTabParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return continueIndented(reader)
+}
+
+func (contentTabParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (contentTabParser) CanInterruptParagraph() bool {
+	return false
+}
+
+func (contentTabParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// continueIndented continues a block whose content is indented, up to the
+// first line that is not
+func continueIndented(reader text.Reader) parser.State {
+	line, _ := reader.PeekLine()
+	if util.IsBlank(line) {
+		reader.Advance(len(line) - 1)
+		return parser.Continue | parser.HasChildren
+	}
+	if indent, _ := util.IndentWidth(line, reader.LineOffset()); indent < contentIndent {
+		return parser.Close
+	}
+	pos, padding := util.IndentPosition(line, reader.LineOffset(), contentIndent)
+	reader.AdvanceAndSetPadding(pos, padding)
+	return parser.Continue | parser.HasChildren
+}
+
+// admonitionStyle is the icon and color of a type of admonition
+type admonitionStyle struct {
+	icon  string
+	color string
+}
+
+// admonitionStyles maps the admonition types of MkDocs Material, and their
+// aliases, to callout styles
+var admonitionStyles = map[string]admonitionStyle{
+	"note":      {":memo:", "blue_background"},
+	"seealso":   {":memo:", "blue_background"},
+	"abstract":  {":clipboard:", "blue_background"},
+	"summary":   {":clipboard:", "blue_background"},
+	"tldr":      {":clipboard:", "blue_background"},
+	"info":      {":information_source:", "blue_background"},
+	"todo":      {":information_source:", "blue_background"},
+	"tip":       {":bulb:", "green_background"},
+	"hint":      {":bulb:", "green_background"},
+	"important": {":bulb:", "green_background"},
+	"success":   {":white_check_mark:", "green_background"},
+	"check":     {":white_check_mark:", "green_background"},
+	"done":      {":white_check_mark:", "green_background"},
+	"question":  {":question:", "yellow_background"},
+	"help":      {":question:", "yellow_background"},
+	"faq":       {":question:", "yellow_background"},
+	"warning":   {":warning:", "yellow_background"},
+	"caution":   {":warning:", "yellow_background"},
+	"attention": {":warning:", "yellow_background"},
+	"failure":   {":x:", "red_background"},
+	"fail":      {":x:", "red_background"},
+	"missing":   {":x:", "red_background"},
+	"danger":    {":zap:", "red_background"},
+	"error":     {":zap:", "red_background"},
+	"bug":       {":bug:", "red_background"},
+	"example":   {":test_tube:", "purple_background"},
+	"quote":     {":speech_balloon:", "gray_background"},
+	"cite":      {":speech_balloon:", "gray_background"},
+}
+
+// convertAdmonition converts an admonition to a callout with the icon and
+// color of its type, or a collapsible one to a toggle. The title, by
+// default the type, is the text of the block and the content its children.
+func (c *Converter) convertAdmonition(node *Admonition, source []byte) (*document.Block, error) {
+	children, err := c.ConvertChildren(node, source)
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.ToUpper(node.Qualifier[:1]) + node.Qualifier[1:]
+	if node.Title != nil {
+		title = *node.Title
+	}
+	var spans []document.Span
+	if title != "" {
+		spans = []document.Span{document.Text(title)}
+	}
+
+	if node.Collapsible {
+		return &document.Block{Kind: document.KindToggle, Spans: spans, Children: children}, nil
+	}
+
+	style, ok := admonitionStyles[node.Qualifier]
+	if !ok {
+		style = admonitionStyles["note"]
+	}
+	block := &document.Block{Kind: document.KindCallout, Color: style.color, Children: children}
+	block.Icon, _ = LookupEmoji(style.icon)
+	if title != "" {
+		for i := range spans {
+			spans[i].Marks.Bold = true
+		}
+		block.Spans = spans
+	} else if len(children) > 0 && children[0].Kind == document.KindParagraph && len(children[0].Children) == 0 {
+		// Without a title the first paragraph is the text of the callout
+		block.Spans = children[0].Spans
+		block.Children = children[1:]
+	}
+	return block, nil
+}
+
+// convertContentTab converts a content tab to a toggle holding its content,
+// as Notion has no tabs
+func (c *Converter) convertContentTab(node *ContentTab, source []byte) (*document.Block, error) {
+	children, err := c.ConvertChildren(node, source)
+	if err != nil {
+		return nil, err
+	}
+	return &document.Block{
+		Kind:     document.KindToggle,
+		Spans:    []document.Span{document.Text(node.Label)},
+		Children: children,
+	}, nil
+}
@@ -0,0 +1,273 @@
+// internal/markdown/obsidian.go
+package markdown
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// obsidian parses the syntax of Obsidian vaults
+type obsidian struct{}
+
+// Extend adds the Obsidian parsers to a Markdown parser
+func (obsidian) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(commentBlockParser{}, 50),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(wikiLinkParser{}, 150),
+			util.Prioritized(tagParser{}, 150),
+			util.Prioritized(commentParser{}, 150),
+		),
+	)
+}
+
+// imageExtensions are the file types Obsidian embeds as images
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true,
+	".webp": true, ".bmp": true, ".avif": true,
+}
+
+// wikiLinkParser parses [[Note]], [[Note#Heading|label]] links and
+// ![[image.png]] embeds into standard links and images, so they are
+// resolved like Markdown links and images
+type wikiLinkParser struct{}
+
+func (wikiLinkParser) Trigger() []byte {
+	return []byte{'!', '['}
+}
+
+func (wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	embed := bytes.HasPrefix(line, []byte("![["))
+	if !embed && !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	start := 2
+	if embed {
+		start = 3
+	}
+	end := bytes.Index(line[start:], []byte("]]"))
+	if end <= 0 {
+		return nil
+	}
+	content := string(line[start : start+end])
+	block.Advance(start + end + 2)
+
+	target, label, _ := strings.Cut(content, "|")
+	target = strings.TrimSpace(target)
+	page, heading, _ := strings.Cut(target, "#")
+
+	if embed && imageExtensions[strings.ToLower(path.Ext(page))] {
+		// ![[image.png|300]] sets a size, not a caption. Image destinations
+		// are paths, resolved like those of Markdown images.
+		image := ast.NewImage(ast.NewLink())
+		image.Destination = []byte(page)
+		return image
+	}
+
+	// Obsidian shows [[Note#Heading]] as "Note > Heading"
+	if label = strings.TrimSpace(label); label == "" {
+		label = strings.Join(slices.DeleteFunc([]string{strings.TrimSuffix(page, ".md"), heading}, func(part string) bool {
+			return part == ""
+		}), " > ")
+	}
+
+	link := ast.NewLink()
+	destination := ""
+	if page != "" {
+		if path.Ext(page) == "" {
+			page += ".md"
+		}
+		destination = (&url.URL{Path: page}).String()
+	}
+	if heading != "" {
+		destination += "#" + document.Slugify(heading)
+	}
+	link.Destination = []byte(destination)
+	link.AppendChild(link, ast.NewString([]byte(label)))
+	return link
+}
+
+// KindTag is the node kind of Obsidian #tags
+var KindTag = ast.NewNodeKind("Tag")
+
+// Tag is an Obsidian #tag
+type Tag struct {
+	ast.BaseInline
+	// Name is the tag without its "#"
+	Name string
+}
+
+// Kind implements ast.Node
+func (n *Tag) Kind() ast.NodeKind {
+	return KindTag
+}
+
+// Dump implements ast.Node
+func (n *Tag) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.Name}, nil)
+}
+
+// tagPattern matches the name of a tag: letters, digits, "_", "-" and "/"
+// for nested tags
+var tagPattern = regexp.MustCompile(`^#([\p{L}\p{N}_/-]+)`)
+
+// tagParser parses #tags at the start of a word. Tags made of digits only,
+// such as issue numbers, are not tags.
+type tagParser struct{}
+
+func (tagParser) Trigger() []byte {
+	return []byte{'#'}
+}
+
+func (tagParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if previous := block.PrecendingCharacter(); !unicode.IsSpace(previous) && previous != '(' {
+		return nil
+	}
+	line, _ := block.PeekLine()
+	m := tagPattern.FindSubmatch(line)
+	if m == nil || bytes.IndexFunc(m[1], func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return nil
+	}
+	block.Advance(len(m[0]))
+	return &Tag{Name: string(m[1])}
+}
+
+// KindComment is the node kind of Obsidian %%comments%% within text
+var KindComment = ast.NewNodeKind("Comment")
+
+// Comment is an Obsidian %%comment%%, hidden from readers
+type Comment struct {
+	ast.BaseInline
+}
+
+// Kind implements ast.Node
+func (n *Comment) Kind() ast.NodeKind {
+	return KindComment
+}
+
+// Dump implements ast.Node
+func (n *Comment) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// commentParser parses a %%comment%% closed on the same line
+type commentParser struct{}
+
+func (commentParser) Trigger() []byte {
+	return []byte{'%'}
+}
+
+func (commentParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("%%")) {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte("%%"))
+	if end < 0 {
+		return nil
+	}
+	block.Advance(end + 4)
+	return &Comment{}
+}
+
+// KindCommentBlock is the node kind of Obsidian %% comment blocks
+var KindCommentBlock = ast.NewNodeKind("CommentBlock")
+
+// CommentBlock is an Obsidian comment spanning whole lines, from a line
+// starting with "%%" to the line holding the closing "%%"
+type CommentBlock struct {
+	ast.BaseBlock
+	closed bool
+}
+
+// Kind implements ast.Node
+func (n *CommentBlock) Kind() ast.NodeKind {
+	return KindCommentBlock
+}
+
+// Dump implements ast.Node
+func (n *CommentBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// IsRaw implements ast.Node, as comments hold no Markdown
+func (n *CommentBlock) IsRaw() bool {
+	return true
+}
+
+// commentBlockParser parses %% comment blocks
+type commentBlockParser struct{}
+
+func (commentBlockParser) Trigger() []byte {
+	return []byte{'%'}
+}
+
+func (commentBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("%%")) {
+		return nil, parser.NoChildren
+	}
+	rest := line[pos+2:]
+	node := &CommentBlock{}
+	if end := bytes.Index(rest, []byte("%%")); end >= 0 {
+		// Text after a comment closed on its opening line makes it inline
+		if !util.IsBlank(rest[end+2:]) {
+			return nil, parser.NoChildren
+		}
+		node.closed = true
+	}
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (commentBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	comment := node.(*CommentBlock)
+	if comment.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	comment.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	if bytes.Contains(line, []byte("%%")) {
+		comment.closed = true
+	}
+	return parser.Continue | parser.NoChildren
+}
+
+func (commentBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (commentBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (commentBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// convertTag converts a #tag to its text and records it as a page tag
+func (c *Converter) convertTag(node *Tag) []document.Span {
+	if !slices.Contains(c.tags, node.Name) {
+		c.tags = append(c.tags, node.Name)
+	}
+	return plainSpans("#" + node.Name)
+}
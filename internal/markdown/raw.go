@@ -0,0 +1,41 @@
+// internal/markdown/raw.go
+package markdown
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+	"github.com/yuin/goldmark/ast"
+)
+
+// rawLanguage is the language of fenced code blocks holding Notion JSON
+const rawLanguage = "notion"
+
+// convertRawBlocks converts a ```notion fenced code block holding a Notion
+// block, or an array of blocks, to blocks passed through verbatim. Invalid
+// JSON is kept as a JSON code block. It reports false when the block is not
+// a ```notion block.
+func (c *Converter) convertRawBlocks(node *ast.FencedCodeBlock, source []byte) ([]*document.Block, bool) {
+	if node.Info == nil {
+		return nil, false
+	}
+	fields := strings.Fields(string(node.Info.Text(source)))
+	if len(fields) == 0 || fields[0] != rawLanguage {
+		return nil, false
+	}
+
+	parsed, err := notion.ParseRawBlocks([]byte(codeBlock(node, source, "").Text()))
+	if err != nil {
+		c.report(node, source, SeverityWarning, KindInvalidRawBlock,
+			fmt.Sprintf("Notion JSON kept as a code block: %v", err))
+		return []*document.Block{codeBlock(node, source, "json")}, true
+	}
+
+	blocks := make([]*document.Block, 0, len(parsed))
+	for _, block := range parsed {
+		blocks = append(blocks, &document.Block{Kind: document.KindRaw, Raw: block.Raw})
+	}
+	return blocks, true
+}
@@ -0,0 +1,41 @@
+// internal/markdown/raw_test.go
+package markdown
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConverter_RawBlocks(t *testing.T) {
+	markdown := "Intro\n\n```notion\n[\n  {\"type\": \"embed\", \"embed\": {\"url\": \"https://example.com\"}},\n" +
+		"  {\"type\": \"divider\", \"divider\": {}}\n]\n```\n\n```notion\n{\"type\": \"paragraph\"}\n```\n"
+
+	blocks, diagnostics, err := NewConverter("", false).Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("got %d blocks, want 4", len(blocks))
+	}
+
+	data, err := json.Marshal(blocks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"type":"embed","embed":{"url":"https://example.com"}}` {
+		t.Errorf("embed = %s, want the JSON verbatim", data)
+	}
+	if blocks[2].Type != "divider" || blocks[1].Source.StartLine != 3 {
+		t.Errorf("raw blocks = %+v", blocks[1:3])
+	}
+
+	// Invalid JSON is kept as code and reported
+	if blocks[3].Code == nil || blocks[3].Code.Language != "json" {
+		t.Errorf("invalid block = %+v, want a JSON code block", blocks[3])
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Kind != KindInvalidRawBlock || diagnostics[0].Line != 11 ||
+		!strings.Contains(diagnostics[0].Message, `paragraph block needs a "paragraph" object`) {
+		t.Errorf("diagnostics = %v", diagnostics)
+	}
+}
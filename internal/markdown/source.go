@@ -0,0 +1,99 @@
+// internal/markdown/source.go
+package markdown
+
+import (
+	"sort"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark/ast"
+)
+
+// sourceRange returns the Markdown lines a node was parsed from
+func (c *Converter) sourceRange(node ast.Node, source []byte) document.SourceRange {
+	start := nodeOffset(node)
+	if fenced, ok := node.(*ast.FencedCodeBlock); ok && fenced.Info != nil {
+		// Start at the opening fence rather than the first line of code
+		start = fenced.Info.Segment.Start
+	}
+	if start < 0 || start > len(source) {
+		return document.SourceRange{}
+	}
+
+	end := lastOffset(node)
+	if end < start {
+		end = start
+	}
+	if end > len(source) {
+		end = len(source)
+	}
+
+	return document.SourceRange{
+		StartLine: c.lineAt(source, start),
+		EndLine:   c.lineAt(source, end),
+	}
+}
+
+// lineAt returns the 1-based line holding the byte at offset. The offsets
+// of the lines are indexed on first use for the current document.
+func (c *Converter) lineAt(source []byte, offset int) int {
+	if c.lineStarts == nil {
+		c.lineStarts = []int{0}
+		for i, b := range source {
+			if b == '\n' {
+				c.lineStarts = append(c.lineStarts, i+1)
+			}
+		}
+	}
+	return c.firstLine - 1 + sort.SearchInts(c.lineStarts, offset+1)
+}
+
+// lastOffset returns the offset of the last source byte covered by a node
+// or its descendants, or -1
+func lastOffset(node ast.Node) int {
+	for child := node.LastChild(); child != nil; child = child.PreviousSibling() {
+		if offset := lastOffset(child); offset >= 0 {
+			return offset
+		}
+	}
+
+	var stop int
+	switch n := node.(type) {
+	case *ast.Text:
+		stop = n.Segment.Stop
+	case *ast.RawHTML:
+		if n.Segments.Len() > 0 {
+			stop = n.Segments.At(n.Segments.Len() - 1).Stop
+		}
+	default:
+		if node.Type() == ast.TypeBlock && node.Lines().Len() > 0 {
+			stop = node.Lines().At(node.Lines().Len() - 1).Stop
+		}
+	}
+	if stop == 0 {
+		return -1
+	}
+	// Segments end after their last byte, which may be a line break
+	return stop - 1
+}
+
+// setSource records the source range on blocks and any nested blocks that
+// do not carry a more precise range of their own
+func setSource(blocks []*document.Block, r document.SourceRange) {
+	for _, b := range blocks {
+		if b.Source.IsZero() {
+			b.Source = r
+		}
+		setSource(b.Children, b.Source)
+	}
+}
+
+// setNode records node as the origin of blocks and any nested blocks not
+// already mapped to a more precise node, for diagnostics reported on blocks
+func (c *Converter) setNode(blocks []*document.Block, node ast.Node) {
+	for _, b := range blocks {
+		if _, ok := c.nodes[b]; !ok {
+			c.nodes[b] = node
+		}
+		c.setNode(b.Children, c.nodes[b])
+	}
+}
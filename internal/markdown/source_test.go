@@ -0,0 +1,66 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+func TestConverter_SourceRanges(t *testing.T) {
+	markdown := strings.Join([]string{
+		"# Title",        // 1
+		"",               // 2
+		"First line",     // 3
+		"second line.",   // 4
+		"",               // 5
+		"```go",          // 6
+		"package main",   // 7
+		"",               // 8
+		"func main() {}", // 9
+		"```",            // 10
+		"",               // 11
+		"| A | B |",      // 12
+		"|---|---|",      // 13
+		"| 1 | 2 |",      // 14
+		"| 3 | 4 |",      // 15
+	}, "\n")
+
+	c := NewConverter("", false)
+	blocks, _, err := c.Convert([]byte(markdown))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	want := []notion.SourceRange{
+		{StartLine: 1, EndLine: 1},
+		{StartLine: 3, EndLine: 4},
+		{StartLine: 6, EndLine: 9},
+		{StartLine: 12, EndLine: 15},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %d blocks, want %d", len(blocks), len(want))
+	}
+	for i, block := range blocks {
+		if block.Source != want[i] {
+			t.Errorf("block %d (%s) source = %s, want %s", i, block.Type, block.Source, want[i])
+		}
+	}
+
+	rows := blocks[3].Table.Children
+	wantRows := []int{12, 14, 15}
+	for i, row := range rows {
+		if row.Source.StartLine != wantRows[i] {
+			t.Errorf("row %d source = %s, want line %d", i, row.Source, wantRows[i])
+		}
+	}
+}
+
+func TestSourceRangeString(t *testing.T) {
+	if got := (notion.SourceRange{StartLine: 4, EndLine: 4}).String(); got != "line 4" {
+		t.Errorf("String() = %q, want %q", got, "line 4")
+	}
+	if got := (notion.SourceRange{StartLine: 4, EndLine: 9}).String(); got != "lines 4-9" {
+		t.Errorf("String() = %q, want %q", got, "lines 4-9")
+	}
+}
@@ -0,0 +1,368 @@
+// internal/markdown/stream.go
+package markdown
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/util"
+)
+
+// streamSegmentSize is the size a segment of a streamed document grows to
+// before it is cut at the next safe blank line
+const streamSegmentSize = 64 << 10
+
+// ConvertStream converts Markdown read from r and sends the top-level blocks
+// to out as they are converted, closing out when done. The document is read
+// and converted in segments cut at blank lines between top-level blocks, so
+// memory use is bounded by the segment size and the largest block rather
+// than by the document size.
+//
+// Headings, footnote numbers and source lines are counted across segments,
+// and link reference definitions resolve the references of later segments.
+// Footnotes only resolve within their segment, and references used before
+// the segment defining them are left as text; both are reported with an
+// unresolved_reference diagnostic. Diagnostics are returned in source order
+// once the whole document is converted.
+func (c *Converter) ConvertStream(ctx context.Context, r io.Reader, out chan<- notion.Block) ([]Diagnostic, error) {
+	return c.convertStream(ctx, r, out, streamSegmentSize)
+}
+
+// convertStream converts a stream in segments of about size bytes
+func (c *Converter) convertStream(ctx context.Context, r io.Reader, out chan<- notion.Block, size int) ([]Diagnostic, error) {
+	defer close(out)
+
+	base := c.begin()
+	base.ctx = ctx
+	base.references = newSegmentReferences()
+	segments := newSegmentReader(r, size)
+	var diagnostics []Diagnostic
+	for {
+		segment, firstLine, err := segments.next()
+		if err != nil {
+			return diagnostics, fmt.Errorf("failed to read markdown: %w", err)
+		}
+		if segment == nil {
+			// Unresolved references are reported once the segment defining
+			// them is read, after the diagnostics of later lines
+			base.diagnostics = diagnostics
+			return base.sortedDiagnostics(), nil
+		}
+
+		conv := base.beginSegment(firstLine)
+		blocks, segmentDiagnostics, err := conv.convert(segment)
+		diagnostics = append(diagnostics, segmentDiagnostics...)
+		diagnostics = append(diagnostics, base.references.take()...)
+		if err != nil {
+			return diagnostics, err
+		}
+		base.footnotes = max(base.footnotes, conv.lastFootnote)
+
+		for _, block := range blocks {
+			select {
+			case out <- block:
+			case <-ctx.Done():
+				return diagnostics, ctx.Err()
+			}
+		}
+	}
+}
+
+// beginSegment returns a copy of the converter with fresh per-document
+// state for a segment starting at firstLine, sharing the state counted
+// across segments
+func (c *Converter) beginSegment(firstLine int) *Converter {
+	conv := *c
+	conv.nodes = make(map[*document.Block]ast.Node)
+	conv.lineStarts = nil
+	conv.diagnostics = nil
+	conv.firstLine = firstLine
+	return &conv
+}
+
+// segmentReferences resolves the references of a streamed document across
+// segments. Link reference definitions are kept for the later segments,
+// while references to footnotes or links defined in another segment are
+// left as text and reported.
+type segmentReferences struct {
+	// links holds the link reference definitions read so far, by label
+	links map[string]parser.Reference
+	// footnotes holds the footnote labels defined so far
+	footnotes map[string]bool
+	// pending holds the diagnostics of references left as text, by the
+	// label of the footnote or link a later segment may define
+	pending map[string][]Diagnostic
+	// diagnostics holds the references found unresolved since the last take
+	diagnostics []Diagnostic
+}
+
+func newSegmentReferences() *segmentReferences {
+	return &segmentReferences{
+		links:     make(map[string]parser.Reference),
+		footnotes: make(map[string]bool),
+		pending:   make(map[string][]Diagnostic),
+	}
+}
+
+// referencePattern matches a footnote reference, or the label of a link
+// reference with the text of a full reference
+var referencePattern = regexp.MustCompile(`\[(\^[^\]\s]+|[^\[\]]+)\](?:\[([^\[\]]*)\])?`)
+
+// footnoteDefinitionPattern matches the start of a footnote definition
+var footnoteDefinitionPattern = regexp.MustCompile(`(?m)^ {0,3}\[\^([^\]\s]+)\]:`)
+
+// update records the definitions of a segment parsed with pc and looks for
+// the references left as text in it
+func (r *segmentReferences) update(c *Converter, pc parser.Context, root ast.Node, source []byte) {
+	var defined []string
+	for _, ref := range pc.References() {
+		label := util.ToLinkReference(ref.Label())
+		if _, ok := r.links[label]; !ok {
+			// The definition outlives its segment, so it must not hold it
+			r.links[label] = parser.NewReference(bytes.Clone(ref.Label()), bytes.Clone(ref.Destination()), bytes.Clone(ref.Title()))
+			defined = append(defined, label)
+		}
+	}
+	// Footnotes referenced in the segment are resolved, the others are
+	// dropped from the document and only found in the source
+	footnotes := make(map[string]bool)
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if footnote, ok := n.(*extast.Footnote); ok && entering {
+			footnotes[string(footnote.Ref)] = true
+		}
+		return ast.WalkContinue, nil
+	})
+	var dropped []string
+	for _, m := range footnoteDefinitionPattern.FindAllSubmatch(source, -1) {
+		if label := string(m[1]); !footnotes[label] {
+			dropped = append(dropped, label)
+		}
+	}
+
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch n.Kind() {
+		case ast.KindCodeBlock, ast.KindFencedCodeBlock, ast.KindHTMLBlock:
+			return ast.WalkSkipChildren, nil
+		}
+		if !entering || n.Type() != ast.TypeBlock {
+			return ast.WalkContinue, nil
+		}
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			r.scan(c, source, line.Start, line.Value(source), footnotes)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for label := range footnotes {
+		dropped = append(dropped, label)
+	}
+	for _, label := range dropped {
+		if !r.footnotes[label] {
+			r.footnotes[label] = true
+			r.resolve("^" + label)
+		}
+	}
+	for _, label := range defined {
+		r.resolve(label)
+	}
+}
+
+// scan looks for the references left as text in a line starting at offset.
+// Footnotes defined in the segment were resolved, like the links defined so
+// far.
+func (r *segmentReferences) scan(c *Converter, source []byte, offset int, line []byte, footnotes map[string]bool) {
+	for _, m := range referencePattern.FindAllSubmatchIndex(line, -1) {
+		if next := m[1]; next < len(line) && (line[next] == '(' || line[next] == ':') {
+			// An inline link or a definition
+			continue
+		}
+
+		label := string(line[m[2]:m[3]])
+		if footnote, ok := strings.CutPrefix(label, "^"); ok {
+			if footnotes[footnote] {
+				continue
+			}
+			d := c.diagnosticAt(source, offset+m[0], SeverityWarning, KindUnresolvedReference,
+				fmt.Sprintf("footnote [%s] is defined in another segment of the streamed document and was left as text", label))
+			if r.footnotes[footnote] {
+				r.diagnostics = append(r.diagnostics, d)
+			} else {
+				r.pending[label] = append(r.pending[label], d)
+			}
+			continue
+		}
+
+		if m[4] >= 0 && m[5] > m[4] {
+			label = string(line[m[4]:m[5]])
+		}
+		label = util.ToLinkReference([]byte(label))
+		if _, ok := r.links[label]; ok {
+			continue
+		}
+		d := c.diagnosticAt(source, offset+m[0], SeverityWarning, KindUnresolvedReference,
+			fmt.Sprintf("link reference [%s] is defined in a later segment of the streamed document and was left as text", label))
+		r.pending[label] = append(r.pending[label], d)
+	}
+}
+
+// resolve reports the references left as text before label was defined
+func (r *segmentReferences) resolve(label string) {
+	r.diagnostics = append(r.diagnostics, r.pending[label]...)
+	delete(r.pending, label)
+}
+
+// take returns the references reported since the last call
+func (r *segmentReferences) take() []Diagnostic {
+	diagnostics := r.diagnostics
+	r.diagnostics = nil
+	return diagnostics
+}
+
+// segmentReader cuts Markdown into segments that convert the same on their
+// own as within the whole document. Segments end at a blank line once they
+// reach the target size, unless the blank line is inside a fenced code
+// block or a multi-line HTML block, or the next line continues a list or
+// indented content.
+type segmentReader struct {
+	r    *bufio.Reader
+	size int
+
+	// line is the number of lines read so far
+	line int
+	// carry is a line read past the end of the previous segment
+	carry []byte
+
+	// fence is the opening fence of the code block being read, htmlEnd the
+	// text closing the HTML block being read
+	fence   []byte
+	htmlEnd []byte
+}
+
+func newSegmentReader(r io.Reader, size int) *segmentReader {
+	return &segmentReader{r: bufio.NewReader(r), size: size}
+}
+
+var (
+	// fencePattern matches the opening fence of a code block
+	fencePattern = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	// listItemPattern matches a line starting a list item
+	listItemPattern = regexp.MustCompile(`^([-*+]|\d{1,9}[.)])([ \t]|\r?\n|$)`)
+	// htmlBlockPattern matches the start of the HTML blocks that may hold
+	// blank lines, with the text that ends them
+	htmlBlockPattern = regexp.MustCompile(`^ {0,3}(<!--|<\?|<![A-Za-z]|<!\[CDATA\[|<(?i:script|pre|style|textarea)[\s>]|<(?i:script|pre|style|textarea)$)`)
+	htmlBlockEnds    = []struct{ start, end string }{
+		{"<!--", "-->"},
+		{"<?", "?>"},
+		{"<![CDATA[", "]]>"},
+		{"<!", ">"},
+	}
+)
+
+// next returns the next segment and the number of its first line, or a nil
+// segment at the end of the input
+func (s *segmentReader) next() ([]byte, int, error) {
+	var segment []byte
+	firstLine := s.line + 1
+	afterBlank := false
+
+	if s.carry != nil {
+		firstLine = s.line
+		segment = append(segment, s.carry...)
+		s.track(s.carry)
+		s.carry = nil
+	}
+
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if len(line) > 0 {
+			s.line++
+			// Cut before a line that starts a new top-level block
+			if afterBlank && len(segment) >= s.size && s.fence == nil && s.htmlEnd == nil && startsBlock(line) {
+				s.carry = line
+				return segment, firstLine, nil
+			}
+			segment = append(segment, line...)
+			afterBlank = s.track(line)
+		}
+		if errors.Is(err, io.EOF) {
+			// segment is nil when there is nothing left
+			return segment, firstLine, nil
+		}
+		if err != nil {
+			return nil, firstLine, err
+		}
+	}
+}
+
+// track updates the code fence and HTML block state with a line and
+// reports whether the line is a blank line outside of them
+func (s *segmentReader) track(line []byte) bool {
+	switch {
+	case s.fence != nil:
+		trimmed := bytes.TrimLeft(line, " ")
+		if len(line)-len(trimmed) <= 3 && bytes.HasPrefix(trimmed, s.fence) &&
+			len(bytes.TrimSpace(bytes.TrimLeft(trimmed, string(s.fence[:1])))) == 0 {
+			s.fence = nil
+		}
+		return false
+
+	case s.htmlEnd != nil:
+		if bytes.Contains(bytes.ToLower(line), s.htmlEnd) {
+			s.htmlEnd = nil
+		}
+		return false
+	}
+
+	if m := fencePattern.FindSubmatch(line); m != nil {
+		s.fence = append([]byte(nil), m[1]...)
+		return false
+	}
+	if m := htmlBlockPattern.FindSubmatch(line); m != nil {
+		end := htmlBlockEnd(m[1])
+		rest := bytes.ToLower(line[bytes.Index(line, m[1])+len(m[1]):])
+		if !bytes.Contains(rest, end) {
+			s.htmlEnd = end
+		}
+		return false
+	}
+	return len(bytes.TrimSpace(line)) == 0
+}
+
+// htmlBlockEnd returns the text that ends an HTML block starting with start
+func htmlBlockEnd(start []byte) []byte {
+	for _, e := range htmlBlockEnds {
+		if bytes.HasPrefix(start, []byte(e.start)) {
+			return []byte(e.end)
+		}
+	}
+	// Raw text elements end at their closing tag
+	tag := bytes.ToLower(bytes.TrimRight(start[1:], " \t\r\n>"))
+	return []byte("</" + string(tag) + ">")
+}
+
+// startsBlock reports whether a line following a blank line starts a new
+// top-level block rather than continuing the previous one
+func startsBlock(line []byte) bool {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return false
+	}
+	switch line[0] {
+	case ' ', '\t':
+		// Indented code or the continuation of a list item or footnote
+		return false
+	}
+	return !listItemPattern.Match(line)
+}
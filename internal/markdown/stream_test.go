@@ -0,0 +1,190 @@
+package markdown
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// streamAll converts markdown with convertStream and collects the blocks
+func streamAll(t *testing.T, c *Converter, markdown string, size int) ([]notion.Block, []Diagnostic) {
+	t.Helper()
+	out := make(chan notion.Block)
+	var blocks []notion.Block
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for block := range out {
+			blocks = append(blocks, block)
+		}
+	}()
+
+	diagnostics, err := c.convertStream(context.Background(), strings.NewReader(markdown), out, size)
+	<-done
+	if err != nil {
+		t.Fatalf("convertStream() error = %v", err)
+	}
+	return blocks, diagnostics
+}
+
+const streamDocument = `# Setup
+
+Intro with <b>HTML</b>.
+
+` + "```go" + `
+func main() {
+
+	fmt.Println("blank line above")
+}
+` + "```" + `
+
+<!-- a comment
+
+spanning blank lines -->
+
+- loose
+  list
+
+- second item
+
+    indented code
+
+  continued
+
+| a | b |
+|---|---|
+| 1 | 2 |
+
+## Setup
+
+` + "```weird" + `
+code
+` + "```" + `
+
+> quote
+
+Last paragraph.
+`
+
+func TestConverter_ConvertStreamMatchesConvert(t *testing.T) {
+	c := NewConverter("", false)
+	want, wantDiagnostics, err := c.Convert([]byte(streamDocument))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	wantJSON, _ := json.Marshal(want)
+
+	// A size of 1 cuts at every blank line where the document may be cut
+	for _, size := range []int{1, 64, 1 << 20} {
+		blocks, diagnostics := streamAll(t, c, streamDocument, size)
+		if got, _ := json.Marshal(blocks); string(got) != string(wantJSON) {
+			t.Errorf("size %d: streamed blocks differ:\n%s\nwant\n%s", size, got, wantJSON)
+		}
+		for i := range blocks {
+			if blocks[i].Anchor != want[i].Anchor || blocks[i].Source != want[i].Source {
+				t.Errorf("size %d: block %d anchored %q at %s, want %q at %s", size, i,
+					blocks[i].Anchor, blocks[i].Source, want[i].Anchor, want[i].Source)
+			}
+		}
+		if !reflect.DeepEqual(diagnostics, wantDiagnostics) {
+			t.Errorf("size %d: diagnostics = %v, want %v", size, diagnostics, wantDiagnostics)
+		}
+	}
+}
+
+func TestConverter_ConvertStreamNumbersFootnotes(t *testing.T) {
+	first := "Text[^a].\n\n[^a]: First note.\n\n"
+	second := "More[^b].\n\n[^b]: Second note.\n"
+
+	c := NewConverter("", false, WithFootnoteLinks(true))
+	blocks, _ := streamAll(t, c, first+second, len(first))
+
+	var anchors, links []string
+	for _, b := range blocks {
+		if b.Anchor != "" {
+			anchors = append(anchors, b.Anchor)
+		}
+		if b.Paragraph != nil {
+			rt := b.Paragraph.RichText[1]
+			links = append(links, rt.Text.Content+" "+*rt.Href)
+		}
+	}
+	if want := []string{"fn-1", "fn-2"}; !reflect.DeepEqual(anchors, want) {
+		t.Errorf("note anchors = %v, want %v", anchors, want)
+	}
+	if want := []string{"¹ #fn-1", "² #fn-2"}; !reflect.DeepEqual(links, want) {
+		t.Errorf("references = %v, want %v", links, want)
+	}
+}
+
+func TestConverter_ConvertStreamResolvesReferences(t *testing.T) {
+	first := "[docs]: https://example.com/docs\n\nEarly [guide][] and note[^late].\n\n"
+	second := "See [the docs][docs] and note[^early].\n\n[^early]: A note defined in the same segment.\n\n"
+	third := "[guide]: https://example.com/guide\n\n[^late]: Late note.\n"
+
+	c := NewConverter("", false)
+	blocks, diagnostics := streamAll(t, c, first+second+third, len(first))
+
+	var href string
+	for _, rt := range blocks[1].Paragraph.RichText {
+		if rt.Href != nil {
+			href = *rt.Href
+		}
+	}
+	if href != "https://example.com/docs" {
+		t.Errorf("link to a definition of an earlier segment = %q", href)
+	}
+
+	var got []string
+	for _, d := range diagnostics {
+		if d.Kind == KindUnresolvedReference {
+			got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Message))
+		}
+	}
+	want := []string{
+		"3:7 link reference [guide] is defined in a later segment of the streamed document and was left as text",
+		"3:25 footnote [^late] is defined in another segment of the streamed document and was left as text",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unresolved references =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSegmentReader(t *testing.T) {
+	segments := newSegmentReader(strings.NewReader(streamDocument), 1)
+	var got []string
+	var lines []int
+	for {
+		segment, line, err := segments.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if segment == nil {
+			break
+		}
+		got = append(got, string(segment))
+		lines = append(lines, line)
+	}
+
+	if strings.Join(got, "") != streamDocument {
+		t.Fatal("segments do not add up to the document")
+	}
+	for i, segment := range got {
+		if want := strings.Count(strings.Join(got[:i], ""), "\n") + 1; lines[i] != want {
+			t.Errorf("segment %d starts at line %d, want %d", i, lines[i], want)
+		}
+		for _, whole := range []string{"blank line above", "spanning blank lines", "second item", "continued"} {
+			if strings.Contains(segment, whole) && strings.HasPrefix(strings.TrimSpace(segment), whole) {
+				t.Errorf("segment %d starts inside a block: %q", i, segment)
+			}
+		}
+	}
+	if len(got) != 9 {
+		t.Errorf("got %d segments, want 9:\n%q", len(got), got)
+	}
+}
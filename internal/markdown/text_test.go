@@ -0,0 +1,59 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/render"
+)
+
+func TestConverter_SplitsLongParagraphText(t *testing.T) {
+	text := strings.Repeat("日本語のテキスト😀", 300)
+	c := NewConverter("", false)
+	blocks, _, err := c.Convert([]byte("**" + text + "**\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].Paragraph == nil {
+		t.Fatalf("got %d blocks, want one paragraph", len(blocks))
+	}
+
+	richText := blocks[0].Paragraph.RichText
+	if len(richText) < 2 {
+		t.Fatalf("got %d rich text elements, want the text split", len(richText))
+	}
+	var joined strings.Builder
+	for i, rt := range richText {
+		if render.TextLength(rt.Text.Content) > render.MaxTextLength {
+			t.Errorf("element %d is over the limit", i)
+		}
+		if rt.Annotations == nil || !rt.Annotations.Bold {
+			t.Errorf("element %d lost its bold annotation", i)
+		}
+		joined.WriteString(rt.Text.Content)
+	}
+	if joined.String() != text {
+		t.Error("split rich text does not add up to the original text")
+	}
+}
+
+func TestConverter_SplitsCodeByUTF16Length(t *testing.T) {
+	// 1500 emoji are 1500 runes but 3000 UTF-16 code units
+	code := strings.Repeat("😀", 1500)
+	c := NewConverter("", false)
+	blocks, _, err := c.Convert([]byte("```\n" + code + "\n```\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+
+	var joined strings.Builder
+	for _, block := range blocks {
+		joined.WriteString(plainText(block.Code.RichText))
+	}
+	if joined.String() != code+"\n" {
+		t.Error("code blocks do not add up to the original code")
+	}
+}
@@ -0,0 +1,135 @@
+// internal/markdown/typography.go
+package markdown
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Typography maps punctuation names to the text the typographer writes for
+// them. Build it with TypographyFor.
+type Typography map[string]string
+
+// punctuations maps the punctuation names of Typography to the goldmark
+// typographer's punctuation
+var punctuations = map[string]extension.TypographicPunctuation{
+	"left_single_quote":  extension.LeftSingleQuote,
+	"right_single_quote": extension.RightSingleQuote,
+	"left_double_quote":  extension.LeftDoubleQuote,
+	"right_double_quote": extension.RightDoubleQuote,
+	"apostrophe":         extension.Apostrophe,
+	"en_dash":            extension.EnDash,
+	"em_dash":            extension.EmDash,
+	"ellipsis":           extension.Ellipsis,
+	"left_angle_quote":   extension.LeftAngleQuote,
+	"right_angle_quote":  extension.RightAngleQuote,
+}
+
+// typographyLocales are the substitutions of each locale. French quotes are
+// guillemets with non-breaking spaces inside, and curly quotes when nested.
+var typographyLocales = map[string]Typography{
+	"en": {
+		"left_single_quote":  "‘",
+		"right_single_quote": "’",
+		"left_double_quote":  "“",
+		"right_double_quote": "”",
+		"apostrophe":         "’",
+		"en_dash":            "–",
+		"em_dash":            "—",
+		"ellipsis":           "…",
+		"left_angle_quote":   "«",
+		"right_angle_quote":  "»",
+	},
+	"fr": {
+		"left_single_quote":  "“",
+		"right_single_quote": "”",
+		"left_double_quote":  "«\u00a0",
+		"right_double_quote": "\u00a0»",
+		"apostrophe":         "’",
+		"en_dash":            "–",
+		"em_dash":            "—",
+		"ellipsis":           "…",
+		"left_angle_quote":   "«\u00a0",
+		"right_angle_quote":  "\u00a0»",
+	},
+}
+
+// TypographyFor returns the substitutions of a locale, "en" or "fr" (region
+// suffixes such as fr-CA are ignored, and "" is "en"), with overrides
+// replacing some of them. Override keys are punctuation names:
+// left_single_quote, right_single_quote, left_double_quote,
+// right_double_quote, apostrophe, en_dash, em_dash, ellipsis,
+// left_angle_quote and right_angle_quote.
+func TypographyFor(locale string, overrides map[string]string) (Typography, error) {
+	language, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	language, _, _ = strings.Cut(language, "_")
+	if language == "" {
+		language = "en"
+	}
+	base, ok := typographyLocales[language]
+	if !ok {
+		return nil, fmt.Errorf("unknown typography locale %q (want en or fr)", locale)
+	}
+
+	t := make(Typography, len(base))
+	for name, value := range base {
+		t[name] = value
+	}
+	for name, value := range overrides {
+		if _, ok := punctuations[name]; !ok {
+			return nil, fmt.Errorf("unknown punctuation %q (want one of %s)", name, strings.Join(punctuationNames(), ", "))
+		}
+		t[name] = value
+	}
+	return t, nil
+}
+
+// punctuationNames returns the sorted punctuation names of Typography
+func punctuationNames() []string {
+	names := make([]string, 0, len(punctuations))
+	for name := range punctuations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithTypography turns straight quotes, apostrophes, "--" and "---" dashes,
+// "..." and "<<" ">>" into the typographic punctuation of t. Code spans, code
+// blocks, links and HTML are never altered. A nil Typography disables it.
+func WithTypography(t Typography) Option {
+	return func(c *Converter) {
+		c.typography = t
+	}
+}
+
+// mergeSpans joins adjacent spans with the same marks and link
+func mergeSpans(spans []document.Span) []document.Span {
+	merged := spans[:0:0]
+	for _, span := range spans {
+		if n := len(merged); n > 0 && merged[n-1].Marks == span.Marks && merged[n-1].Href == span.Href {
+			merged[n-1].Text += span.Text
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// typographer returns the goldmark extension applying the typography
+func (t Typography) typographer() goldmark.Extender {
+	substitutions := make(map[extension.TypographicPunctuation]string, len(punctuations))
+	for name, punctuation := range punctuations {
+		value, ok := t[name]
+		if !ok {
+			value = typographyLocales["en"][name]
+		}
+		substitutions[punctuation] = value
+	}
+	return extension.NewTypographer(extension.WithTypographicSubstitutions(substitutions))
+}
@@ -0,0 +1,67 @@
+// internal/markdown/typography_test.go
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConverter_Typography(t *testing.T) {
+	markdown := "Say \"hi\" -- it's *fine*... `\"code\" -- x` and [\"docs\"](https://x.test/a--b)\n\n" +
+		"```\n\"raw\" -- ...\n```\n"
+
+	tests := []struct {
+		name      string
+		locale    string
+		overrides map[string]string
+		want      string
+	}{
+		{"english", "en", nil, "Say “hi” – it’s fine… \"code\" -- x and “docs”"},
+		{"french", "fr-CA", nil, "Say « hi » – it’s fine… \"code\" -- x and « docs »"},
+		{"overrides", "", map[string]string{"en_dash": "--", "left_double_quote": "„"}, "Say „hi” -- it’s fine… \"code\" -- x and „docs”"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typography, err := TypographyFor(tt.locale, tt.overrides)
+			if err != nil {
+				t.Fatalf("TypographyFor() error = %v", err)
+			}
+			blocks, _, err := NewConverter("", false, WithTypography(typography)).Convert([]byte(markdown))
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if len(blocks) != 2 {
+				t.Fatalf("got %d blocks, want 2", len(blocks))
+			}
+
+			var text strings.Builder
+			richText := blocks[0].Paragraph.RichText
+			for _, rt := range richText {
+				text.WriteString(rt.Text.Content)
+			}
+			if text.String() != tt.want {
+				t.Errorf("paragraph = %q, want %q", text.String(), tt.want)
+			}
+			// Spans are merged around substitutions: plain, italic, plain, code, plain, link
+			if len(richText) != 6 {
+				t.Errorf("got %d rich text items, want 6", len(richText))
+			}
+			if href := richText[5].Href; href == nil || *href != "https://x.test/a--b" {
+				t.Errorf("link = %v", href)
+			}
+			if code := blocks[1].Code.RichText[0].Text.Content; code != "\"raw\" -- ...\n" {
+				t.Errorf("code block = %q", code)
+			}
+		})
+	}
+}
+
+func TestTypographyForErrors(t *testing.T) {
+	if _, err := TypographyFor("de", nil); err == nil || !strings.Contains(err.Error(), `unknown typography locale "de"`) {
+		t.Errorf("got error %v for an unknown locale", err)
+	}
+	if _, err := TypographyFor("en", map[string]string{"quote": "x"}); err == nil || !strings.Contains(err.Error(), `unknown punctuation "quote"`) {
+		t.Errorf("got error %v for an unknown punctuation", err)
+	}
+}
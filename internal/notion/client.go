@@ -5,12 +5,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,6 +25,9 @@ const (
 	// BlockChunkSize defines the maximum number of blocks to send in a single API call
 	// Notion's limit is 100, but we use 50 for better reliability with large documents
 	BlockChunkSize = 50
+	// MaxNestingDepth is the number of levels of children Notion accepts
+	// below the blocks of a single append request
+	MaxNestingDepth = 2
 )
 
 // Client handles Notion API interactions
@@ -30,6 +36,10 @@ type Client struct {
 	token      string
 	version    string
 	verbose    bool
+
+	// uploads maps content hashes to file upload IDs already sent
+	uploadsMu sync.Mutex
+	uploads   map[string]string
 }
 
 // NewClient creates a new Notion API client
@@ -42,6 +52,12 @@ func NewClient(token, version string, timeout time.Duration, verbose bool) *Clie
 	}
 }
 
+// SetHTTPClient replaces the HTTP client used for API requests, e.g. to
+// route requests through a proxy or a custom transport
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
 // formatPageID formats a page ID to the proper UUID format with dashes
 func (c *Client) formatPageID(pageID string) string {
 	// Remove any existing dashes and lowercase
@@ -79,14 +95,8 @@ func (c *Client) processBlocksInChunks(ctx context.Context, blocks []Block, proc
 			end = len(blocks)
 		}
 
-		chunk := blocks[i:end]
-
-		if err := processFn(ctx, chunk); err != nil {
-			return fmt.Errorf("failed to process blocks (chunk %d-%d): %w", i+1, end, err)
-		}
-
-		if c.verbose {
-			fmt.Fprintf(os.Stderr, "Processed %d blocks (chunk %d-%d)\n", len(chunk), i+1, end)
+		if err := c.processChunk(ctx, blocks[i:end], i, processFn); err != nil {
+			return err
 		}
 
 		// Small pause between chunks to be nice to the API
@@ -98,22 +108,219 @@ func (c *Client) processBlocksInChunks(ctx context.Context, blocks []Block, proc
 	return nil
 }
 
+// processChunk processes one chunk of blocks starting at offset in the
+// blocks being sent
+func (c *Client) processChunk(ctx context.Context, chunk []Block, offset int, processFn func(ctx context.Context, chunk []Block) error) error {
+	end := offset + len(chunk)
+	if err := processFn(ctx, chunk); err != nil {
+		// Point validation errors at the rejected blocks
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.locateBlocks(chunk, offset)
+		}
+		return fmt.Errorf("failed to process blocks (chunk %d-%d): %w", offset+1, end, err)
+	}
+
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "Processed %d blocks (chunk %d-%d)\n", len(chunk), offset+1, end)
+	}
+	return nil
+}
+
 // AppendBlockChildren appends blocks to a page or block
 // Blocks are automatically split into chunks to respect Notion's 100-block limit per API call.
 // Uses a chunk size of 50 for better reliability with large documents.
-func (c *Client) AppendBlockChildren(ctx context.Context, blockID string, blocks []Block) error {
+// The created top-level blocks are returned in order, carrying their new IDs.
+func (c *Client) AppendBlockChildren(ctx context.Context, blockID string, blocks []Block) ([]Block, error) {
 	formattedID := c.formatPageID(blockID)
+	var created []Block
 
-	return c.processBlocksInChunks(ctx, blocks, func(ctx context.Context, chunk []Block) error {
-		req := AppendBlockChildrenRequest{Children: chunk}
-		return c.makeRequest(ctx, "PATCH", fmt.Sprintf("/blocks/%s/children", formattedID), req, nil)
+	err := c.processBlocksInChunks(ctx, blocks, func(ctx context.Context, chunk []Block) error {
+		results, err := c.appendChunk(ctx, formattedID, chunk)
+		created = append(created, results...)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// AppendBlockStream appends the blocks received from blocks to a page or
+// block as they arrive, in chunks like AppendBlockChildren, until the channel
+// is closed. After each chunk is created, onChunk is called with the chunk
+// and the created blocks, so callers can process them without keeping the
+// whole document. It returns the number of blocks appended. On error it
+// stops reading blocks; producers should stop when ctx is cancelled.
+func (c *Client) AppendBlockStream(ctx context.Context, blockID string, blocks <-chan Block, onChunk func(chunk, created []Block) error) (int, error) {
+	formattedID := c.formatPageID(blockID)
+	appended := 0
+
+	chunk := make([]Block, 0, BlockChunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		var created []Block
+		err := c.processChunk(ctx, chunk, appended, func(ctx context.Context, chunk []Block) error {
+			var err error
+			created, err = c.appendChunk(ctx, formattedID, chunk)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		appended += len(chunk)
+		if onChunk != nil {
+			if err := onChunk(chunk, created); err != nil {
+				return err
+			}
+		}
+		// The chunk was handed to onChunk, which may keep it
+		chunk = make([]Block, 0, BlockChunkSize)
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return appended, ctx.Err()
+		case block, ok := <-blocks:
+			if !ok {
+				return appended, flush()
+			}
+			chunk = append(chunk, block)
+			if len(chunk) == BlockChunkSize {
+				if err := flush(); err != nil {
+					return appended, err
+				}
+			}
+		}
+	}
+}
+
+// appendChunk appends one chunk of blocks and returns the created blocks.
+// Children nested deeper than Notion accepts in one request are appended
+// to their created parents in follow-up requests.
+func (c *Client) appendChunk(ctx context.Context, formattedID string, chunk []Block) ([]Block, error) {
+	sent, deferred := limitNesting(chunk)
+	req := AppendBlockChildrenRequest{Children: sent}
+	var resp ListBlockChildrenResponse
+	if err := c.makeRequest(ctx, "PATCH", fmt.Sprintf("/blocks/%s/children", formattedID), req, &resp); err != nil {
+		return nil, err
+	}
+	if err := c.appendDeferred(ctx, chunk, resp.Results, deferred); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// deferredChildren are nested blocks held back from an append request
+type deferredChildren struct {
+	// block is the index of a block in the request and child the index of
+	// its child the blocks are appended to
+	block, child int
+	children     []Block
+}
+
+// limitNesting returns the blocks with the children nested deeper than
+// MaxNestingDepth held back. The children of a child are held back as a
+// whole, so tables keep their rows. blocks are left untouched.
+func limitNesting(blocks []Block) ([]Block, []deferredChildren) {
+	var sent []Block
+	var deferred []deferredChildren
+	for i := range blocks {
+		children := blocks[i].ChildBlocks()
+		var limited []Block
+		for j := range children {
+			nested := children[j].ChildBlocks()
+			if !slices.ContainsFunc(nested, func(b Block) bool { return len(b.ChildBlocks()) > 0 }) {
+				continue
+			}
+			if limited == nil {
+				limited = slices.Clone(children)
+			}
+			limited[j] = children[j].withChildBlocks(nil)
+			deferred = append(deferred, deferredChildren{block: i, child: j, children: nested})
+		}
+		if limited != nil {
+			if sent == nil {
+				sent = slices.Clone(blocks)
+			}
+			sent[i] = blocks[i].withChildBlocks(limited)
+		}
+	}
+	if sent == nil {
+		return blocks, nil
+	}
+	return sent, deferred
+}
+
+// appendDeferred appends held back children to the blocks created for the
+// chunk they were held back from. Blocks rejected by Notion are located at
+// their top-level block in the chunk.
+func (c *Client) appendDeferred(ctx context.Context, chunk, created []Block, deferred []deferredChildren) error {
+	listed := make(map[string][]Block)
+	for _, d := range deferred {
+		if d.block >= len(created) {
+			return fmt.Errorf("nested blocks of block %d were not created", d.block+1)
+		}
+		parentID := created[d.block].ID
+		children, ok := listed[parentID]
+		if !ok {
+			var err error
+			if children, err = c.ListBlockChildren(ctx, parentID); err != nil {
+				return err
+			}
+			listed[parentID] = children
+		}
+		if d.child >= len(children) {
+			return fmt.Errorf("nested blocks of block %d were not created", d.block+1)
+		}
+		if _, err := c.AppendBlockChildren(ctx, children[d.child].ID, d.children); err != nil {
+			// Errors are formatted when wrapped, so the API error is
+			// returned as is, to be wrapped once located in the chunk
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				apiErr.nestBlocks(chunk, d.block, d.child)
+				return apiErr
+			}
+			return fmt.Errorf("failed to append nested blocks: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateBlock replaces the content of an existing block with the content of block
+// Only the type payload is sent; nested children are left untouched.
+func (c *Client) UpdateBlock(ctx context.Context, block Block) error {
+	if block.ID == "" {
+		return fmt.Errorf("cannot update block without an ID")
+	}
+
+	data, err := json.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("failed to marshal block: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to decode block: %w", err)
+	}
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(fields[block.Type], &payload); err != nil {
+		return fmt.Errorf("block %s has no %s content: %w", block.ID, block.Type, err)
+	}
+	delete(payload, "children")
+
+	req := map[string]interface{}{block.Type: payload}
+	return c.makeRequest(ctx, "PATCH", fmt.Sprintf("/blocks/%s", c.formatPageID(block.ID)), req, nil)
 }
 
 // CreatePage creates a new page under a parent page
 // The page is created first without children, then blocks are appended in chunks
-// to avoid Notion's 100-block limit per API call.
-func (c *Client) CreatePage(ctx context.Context, parentID, title string, blocks []Block) (*PageResponse, error) {
+// to avoid Notion's 100-block limit per API call. icon may be nil.
+func (c *Client) CreatePage(ctx context.Context, parentID, title string, icon *Icon, blocks []Block) (*PageResponse, error) {
 	formattedParentID := c.formatPageID(parentID)
 	titleText := []RichText{{
 		Type: "text",
@@ -126,6 +333,7 @@ func (c *Client) CreatePage(ctx context.Context, parentID, title string, blocks
 			Type:   "page_id",
 			PageID: formattedParentID,
 		},
+		Icon: icon,
 		Properties: PageProperties{
 			Title: TitleProperty{Title: titleText},
 		},
@@ -139,7 +347,7 @@ func (c *Client) CreatePage(ctx context.Context, parentID, title string, blocks
 
 	// If there are blocks to add, append them in chunks after page creation
 	if len(blocks) > 0 {
-		if err := c.AppendBlockChildren(ctx, resp.ID, blocks); err != nil {
+		if _, err := c.AppendBlockChildren(ctx, resp.ID, blocks); err != nil {
 			return nil, fmt.Errorf("failed to add content to page: %w", err)
 		}
 	}
@@ -171,7 +379,7 @@ func (c *Client) CreatePageInDatabase(ctx context.Context, databaseID string, pr
 
 	// If there are blocks to add, append them in chunks after page creation
 	if len(blocks) > 0 {
-		if err := c.AppendBlockChildren(ctx, resp.ID, blocks); err != nil {
+		if _, err := c.AppendBlockChildren(ctx, resp.ID, blocks); err != nil {
 			return nil, fmt.Errorf("failed to add content to page: %w", err)
 		}
 	}
@@ -179,6 +387,24 @@ func (c *Client) CreatePageInDatabase(ctx context.Context, databaseID string, pr
 	return &resp, nil
 }
 
+// SetPageIcon replaces the icon of an existing page
+func (c *Client) SetPageIcon(ctx context.Context, pageID string, icon *Icon) error {
+	req := UpdatePageRequest{Icon: icon}
+	return c.makeRequest(ctx, "PATCH", fmt.Sprintf("/pages/%s", c.formatPageID(pageID)), req, nil)
+}
+
+// SetPageTags sets the multi-select property of a database page to tags
+func (c *Client) SetPageTags(ctx context.Context, pageID, property string, tags []string) error {
+	options := make([]SelectOption, 0, len(tags))
+	for _, tag := range tags {
+		options = append(options, SelectOption{Name: tag})
+	}
+	req := UpdatePageRequest{Properties: map[string]PropertyValue{
+		property: {MultiSelect: options},
+	}}
+	return c.makeRequest(ctx, "PATCH", fmt.Sprintf("/pages/%s", c.formatPageID(pageID)), req, nil)
+}
+
 // ListBlockChildren retrieves all child blocks of a block
 func (c *Client) ListBlockChildren(ctx context.Context, blockID string) ([]Block, error) {
 	formattedID := c.formatPageID(blockID)
@@ -214,17 +440,15 @@ func (c *Client) DeleteBlock(ctx context.Context, blockID string) error {
 	return c.makeRequest(ctx, "PATCH", fmt.Sprintf("/blocks/%s", formattedID), req, nil)
 }
 
-// makeRequest performs an HTTP request with retry logic
+// makeRequest performs a JSON HTTP request with retry logic
 func (c *Client) makeRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
-	url := NotionAPIBase + path
-
-	var reqBody io.Reader
+	var payload []byte
 	if body != nil {
 		jsonData, err := json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewReader(jsonData)
+		payload = jsonData
 
 		if c.verbose {
 			fmt.Fprintf(os.Stderr, "Request: %s %s\n", method, path)
@@ -232,6 +456,13 @@ func (c *Client) makeRequest(ctx context.Context, method, path string, body inte
 		}
 	}
 
+	return c.doRequest(ctx, method, path, "application/json", payload, result)
+}
+
+// doRequest sends an already encoded request body with retry logic
+func (c *Client) doRequest(ctx context.Context, method, path, contentType string, payload []byte, result interface{}) error {
+	url := NotionAPIBase + path
+
 	var lastErr error
 	backoff := BaseBackoff
 
@@ -242,12 +473,12 @@ func (c *Client) makeRequest(ctx context.Context, method, path string, body inte
 				return ctx.Err()
 			case <-time.After(backoff):
 			}
+		}
 
-			// Reset body reader for retry
-			if body != nil {
-				jsonData, _ := json.Marshal(body)
-				reqBody = bytes.NewReader(jsonData)
-			}
+		// Use a fresh body reader for every attempt
+		var reqBody io.Reader
+		if payload != nil {
+			reqBody = bytes.NewReader(payload)
 		}
 
 		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
@@ -256,7 +487,7 @@ func (c *Client) makeRequest(ctx context.Context, method, path string, body inte
 		}
 
 		req.Header.Set("Authorization", "Bearer "+c.token)
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", contentType)
 		req.Header.Set("Notion-Version", c.version)
 		req.Header.Set("User-Agent", "md2notion/1.0")
 
@@ -354,12 +585,12 @@ func (c *Client) nextBackoff(current time.Duration) time.Duration {
 func (c *Client) handleErrorResponse(statusCode int, body []byte) error {
 	var notionErr ErrorResponse
 	if json.Unmarshal(body, &notionErr) == nil && notionErr.Message != "" {
-		return fmt.Errorf("notion API error (%d): %s (code: %s)", statusCode, notionErr.Message, notionErr.Code)
+		return &APIError{StatusCode: statusCode, Code: notionErr.Code, Message: notionErr.Message}
 	}
 
 	// Fallback to raw response
 	bodyPreview := c.truncateBody(string(body))
-	return fmt.Errorf("notion API error (%d): %s", statusCode, bodyPreview)
+	return &APIError{StatusCode: statusCode, Message: bodyPreview}
 }
 
 // truncateBody truncates response body for error messages
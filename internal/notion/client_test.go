@@ -2,6 +2,12 @@ package notion
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -66,3 +72,380 @@ func TestProcessBlocksInChunks(t *testing.T) {
 		t.Errorf("Expected to process 126 blocks, processed %d", totalProcessed)
 	}
 }
+
+// roundTripFunc lets tests stub the HTTP transport of a Client
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newTestClient(fn roundTripFunc) *Client {
+	return &Client{httpClient: &http.Client{Transport: fn}, token: "test", version: "2022-06-28"}
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestAppendBlockChildrenReturnsCreatedBlocks(t *testing.T) {
+	calls := 0
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		var body AppendBlockChildrenRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		var results []string
+		for i := range body.Children {
+			results = append(results, fmt.Sprintf(`{"object":"block","id":"c%d-%d","type":"paragraph"}`, calls, i))
+		}
+		return jsonResponse(200, `{"object":"list","results":[`+strings.Join(results, ",")+`]}`), nil
+	})
+
+	blocks := make([]Block, BlockChunkSize+2)
+	for i := range blocks {
+		blocks[i] = Block{Object: "block", Type: "paragraph", Paragraph: &Paragraph{}}
+	}
+
+	created, err := client.AppendBlockChildren(context.Background(), "page", blocks)
+	if err != nil {
+		t.Fatalf("AppendBlockChildren() error = %v", err)
+	}
+	if len(created) != len(blocks) {
+		t.Fatalf("got %d created blocks, want %d", len(created), len(blocks))
+	}
+	if created[BlockChunkSize].ID != "c2-0" {
+		t.Errorf("first block of second chunk has ID %q, want %q", created[BlockChunkSize].ID, "c2-0")
+	}
+}
+
+func TestAppendBlockChildrenDefersDeepChildren(t *testing.T) {
+	// The fake API stores the created tree and rejects requests nested too deep
+	tree := make(map[string][]string)
+	created := 0
+	var store func(parent string, blocks []Block) []string
+	store = func(parent string, blocks []Block) []string {
+		var ids []string
+		for _, b := range blocks {
+			created++
+			id := fmt.Sprintf("b%d", created)
+			tree[parent] = append(tree[parent], id)
+			ids = append(ids, id)
+			store(id, b.ChildBlocks())
+		}
+		return ids
+	}
+	var depth func(blocks []Block) int
+	depth = func(blocks []Block) int {
+		deepest := 0
+		for _, b := range blocks {
+			if children := b.ChildBlocks(); len(children) > 0 {
+				deepest = max(deepest, 1+depth(children))
+			}
+		}
+		return deepest
+	}
+	results := func(ids []string) string {
+		var items []string
+		for _, id := range ids {
+			items = append(items, fmt.Sprintf(`{"object":"block","id":%q,"type":"paragraph"}`, id))
+		}
+		return `{"object":"list","results":[` + strings.Join(items, ",") + `]}`
+	}
+
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		id := strings.Split(strings.TrimPrefix(req.URL.Path, "/v1/blocks/"), "/")[0]
+		if req.Method == "GET" {
+			return jsonResponse(200, results(tree[id])), nil
+		}
+		var body AppendBlockChildrenRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if d := depth(body.Children); d > MaxNestingDepth {
+			return jsonResponse(400, `{"object":"error","status":400,"code":"validation_error","message":"too deep"}`), nil
+		}
+		return jsonResponse(200, results(store(id, body.Children))), nil
+	})
+
+	item := func(text string, children ...Block) Block {
+		return Block{Object: "block", Type: "bulleted_list_item", BulletedListItem: &BulletedListItem{
+			RichText: []RichText{{Type: "text", Text: &Text{Content: text}}},
+			Children: children,
+		}}
+	}
+	blocks := []Block{
+		{Object: "block", Type: "toggle", Toggle: &Toggle{Children: []Block{
+			{Object: "block", Type: "callout", Callout: &Callout{Children: []Block{
+				item("a", item("b", item("c"))),
+			}}},
+		}}},
+		{Object: "block", Type: "paragraph", Paragraph: &Paragraph{}},
+	}
+
+	if _, err := client.AppendBlockChildren(context.Background(), "page", blocks); err != nil {
+		t.Fatalf("AppendBlockChildren() error = %v", err)
+	}
+	var shape func(id string) string
+	shape = func(id string) string {
+		var b strings.Builder
+		for _, child := range tree[id] {
+			b.WriteString("[" + shape(child) + "]")
+		}
+		return b.String()
+	}
+	if got, want := shape("page"), "[[[[[]]]]][]"; got != want {
+		t.Errorf("created tree = %s, want %s", got, want)
+	}
+	if len(blocks[0].Toggle.Children[0].Callout.Children) != 1 || len(blocks[0].Toggle.Children[0].Callout.Children[0].ChildBlocks()) != 1 {
+		t.Error("AppendBlockChildren() modified the blocks passed to it")
+	}
+}
+
+func TestUpdateBlockSendsTypePayloadOnly(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]map[string]json.RawMessage
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		gotPath = req.URL.Path
+		if err := json.NewDecoder(req.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		return jsonResponse(200, `{}`), nil
+	})
+
+	href := "https://notion.so/page#block"
+	block := Block{
+		Object: "block",
+		ID:     "0123456789abcdef0123456789abcdef",
+		Type:   "bulleted_list_item",
+		BulletedListItem: &BulletedListItem{
+			RichText: []RichText{{Type: "text", Text: &Text{Content: "x"}, Href: &href}},
+			Children: []Block{{Object: "block", Type: "divider", Divider: &Divider{}}},
+		},
+	}
+	if err := client.UpdateBlock(context.Background(), block); err != nil {
+		t.Fatalf("UpdateBlock() error = %v", err)
+	}
+
+	if gotPath != "/v1/blocks/01234567-89ab-cdef-0123-456789abcdef" {
+		t.Errorf("request path = %q", gotPath)
+	}
+	if len(gotBody) != 1 || gotBody["bulleted_list_item"] == nil {
+		t.Fatalf("request body keys = %v, want only bulleted_list_item", gotBody)
+	}
+	if _, ok := gotBody["bulleted_list_item"]["children"]; ok {
+		t.Errorf("request body must not include children")
+	}
+	if _, ok := gotBody["bulleted_list_item"]["rich_text"]; !ok {
+		t.Errorf("request body is missing rich_text")
+	}
+}
+
+func TestUploadFileDeduplicatesByContent(t *testing.T) {
+	var paths []string
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		switch {
+		case req.URL.Path == "/v1/file_uploads":
+			var body CreateFileUploadRequest
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			if body.Mode != "single_part" || body.Filename != "a.png" || body.ContentType != "image/png" {
+				t.Errorf("create upload request = %+v", body)
+			}
+			return jsonResponse(200, `{"object":"file_upload","id":"up1","status":"pending"}`), nil
+		case req.URL.Path == "/v1/file_uploads/up1/send":
+			if err := req.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatalf("failed to parse multipart body: %v", err)
+			}
+			file, header, err := req.FormFile("file")
+			if err != nil {
+				t.Fatalf("missing file part: %v", err)
+			}
+			data, _ := io.ReadAll(file)
+			if string(data) != "png-bytes" || header.Header.Get("Content-Type") != "image/png" {
+				t.Errorf("sent file = %q (%s)", data, header.Header.Get("Content-Type"))
+			}
+			return jsonResponse(200, `{"object":"file_upload","id":"up1","status":"uploaded"}`), nil
+		}
+		t.Errorf("unexpected request to %s", req.URL.Path)
+		return jsonResponse(404, `{}`), nil
+	})
+
+	file := FileData{Name: "a.png", ContentType: "image/png", Data: []byte("png-bytes")}
+	for i := 0; i < 2; i++ {
+		id, err := client.UploadFile(context.Background(), file)
+		if err != nil {
+			t.Fatalf("UploadFile() error = %v", err)
+		}
+		if id != "up1" {
+			t.Errorf("UploadFile() = %q, want %q", id, "up1")
+		}
+	}
+
+	if len(paths) != 2 {
+		t.Errorf("made %d requests (%v), want 2", len(paths), paths)
+	}
+}
+
+func TestAppendBlockChildrenLocatesRejectedBlocks(t *testing.T) {
+	calls := 0
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return jsonResponse(200, `{"object":"list","results":[]}`), nil
+		}
+		return jsonResponse(400, `{"object":"error","status":400,"code":"validation_error",`+
+			`"message":"body failed validation: body.children[2].table.children[1].table_row.cells[0] should be defined, instead was `+"`undefined`"+`."}`), nil
+	})
+
+	blocks := make([]Block, BlockChunkSize+3)
+	for i := range blocks {
+		blocks[i] = Block{Object: "block", Type: "paragraph", Paragraph: &Paragraph{},
+			Source: SourceRange{StartLine: i + 1, EndLine: i + 1}}
+	}
+	blocks[BlockChunkSize+2] = Block{Object: "block", Type: "table", Table: &Table{Children: []Block{
+		{Type: "table_row", Source: SourceRange{StartLine: 90, EndLine: 90}},
+		{Type: "table_row", Source: SourceRange{StartLine: 92, EndLine: 92}},
+	}}}
+
+	_, err := client.AppendBlockChildren(context.Background(), "page", blocks)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("AppendBlockChildren() error = %v, want an APIError", err)
+	}
+	if apiErr.Code != "validation_error" || len(apiErr.Blocks) != 1 {
+		t.Fatalf("APIError = %+v, want one validation_error block", apiErr)
+	}
+
+	failed := apiErr.Blocks[0]
+	if failed.Index != BlockChunkSize+2 || failed.Block.Type != "table_row" || failed.Block.Source.StartLine != 92 {
+		t.Errorf("failed block = %d %s %s, want %d table_row line 92",
+			failed.Index, failed.Block.Type, failed.Block.Source, BlockChunkSize+2)
+	}
+	if !strings.Contains(err.Error(), "from Markdown line 92") {
+		t.Errorf("error %q does not name the Markdown line", err)
+	}
+}
+
+func TestAppendBlockChildrenLocatesRejectedNestedBlocks(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		switch id := strings.Split(strings.TrimPrefix(req.URL.Path, "/v1/blocks/"), "/")[0]; {
+		case req.Method == "GET":
+			return jsonResponse(200, `{"object":"list","results":[{"object":"block","id":"callout","type":"callout"}]}`), nil
+		case id == "callout":
+			return jsonResponse(400, `{"object":"error","status":400,"code":"validation_error","message":"body failed validation: `+
+				`body.children[0].bulleted_list_item.children[0].bulleted_list_item.children[0].bulleted_list_item.rich_text[0].text.content.length should be ≤ 2000."}`), nil
+		}
+		var body AppendBlockChildrenRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		var results []string
+		for i := range body.Children {
+			results = append(results, fmt.Sprintf(`{"object":"block","id":"b%d","type":"paragraph"}`, i))
+		}
+		return jsonResponse(200, `{"object":"list","results":[`+strings.Join(results, ",")+`]}`), nil
+	})
+
+	item := func(line int, children ...Block) Block {
+		return Block{Object: "block", Type: "bulleted_list_item", BulletedListItem: &BulletedListItem{Children: children},
+			Source: SourceRange{StartLine: line, EndLine: line}}
+	}
+	blocks := make([]Block, BlockChunkSize+2)
+	for i := range blocks {
+		blocks[i] = Block{Object: "block", Type: "paragraph", Paragraph: &Paragraph{}}
+	}
+	blocks[BlockChunkSize+1] = Block{Object: "block", Type: "toggle", Toggle: &Toggle{Children: []Block{
+		{Object: "block", Type: "callout", Callout: &Callout{Children: []Block{item(5, item(6, item(7)))}}},
+	}}}
+
+	_, err := client.AppendBlockChildren(context.Background(), "page", blocks)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || len(apiErr.Blocks) != 1 {
+		t.Fatalf("AppendBlockChildren() error = %v, want an APIError locating one block", err)
+	}
+
+	failed := apiErr.Blocks[0]
+	wantPath := "body.children[1].toggle.children[0].callout.children[0].bulleted_list_item.children[0].bulleted_list_item.children[0]"
+	if failed.Index != BlockChunkSize+1 || failed.Path != wantPath || failed.Block.Source.StartLine != 7 {
+		t.Errorf("failed block = %d %s %s, want %d %s line 7", failed.Index, failed.Path, failed.Block.Source, BlockChunkSize+1, wantPath)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("block %d (bulleted_list_item) from Markdown line 7", BlockChunkSize+2)) {
+		t.Errorf("error %q does not name the top-level block and the Markdown line", err)
+	}
+}
+
+func TestAPIErrorWithoutBlockPath(t *testing.T) {
+	err := (&Client{}).handleErrorResponse(401, []byte(`{"object":"error","status":401,"code":"unauthorized","message":"API token is invalid."}`))
+	want := "notion API error (401): API token is invalid. (code: unauthorized)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestAppendBlockStream(t *testing.T) {
+	var sizes []int
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		var body AppendBlockChildrenRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, len(body.Children))
+		results := make([]string, len(body.Children))
+		for i := range results {
+			results[i] = fmt.Sprintf(`{"object":"block","id":"b%d","type":"paragraph"}`, i)
+		}
+		return jsonResponse(200, `{"object":"list","results":[`+strings.Join(results, ",")+`]}`), nil
+	})
+
+	blocks := make(chan Block)
+	go func() {
+		defer close(blocks)
+		for i := 0; i < BlockChunkSize*2+7; i++ {
+			blocks <- Block{Object: "block", Type: "paragraph", Paragraph: &Paragraph{}}
+		}
+	}()
+
+	var chunks, created int
+	appended, err := client.AppendBlockStream(context.Background(), "page", blocks, func(chunk, results []Block) error {
+		chunks++
+		if len(results) != len(chunk) {
+			t.Errorf("chunk of %d blocks got %d created blocks", len(chunk), len(results))
+		}
+		created += len(results)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AppendBlockStream() error = %v", err)
+	}
+
+	if appended != BlockChunkSize*2+7 || created != appended {
+		t.Errorf("appended %d blocks, %d created, want %d", appended, created, BlockChunkSize*2+7)
+	}
+	if want := []int{BlockChunkSize, BlockChunkSize, 7}; fmt.Sprint(sizes) != fmt.Sprint(want) || chunks != 3 {
+		t.Errorf("sent chunks of %v, want %v", sizes, want)
+	}
+}
+
+func TestAppendBlockStreamStopsOnCancel(t *testing.T) {
+	client := newTestClient(func(req *http.Request) (*http.Response, error) {
+		t.Error("no request expected")
+		return nil, errors.New("unexpected request")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// The channel is never closed: the append must stop on the context
+	_, err := client.AppendBlockStream(ctx, "page", make(chan Block), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("AppendBlockStream() error = %v, want context.Canceled", err)
+	}
+}
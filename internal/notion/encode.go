@@ -0,0 +1,60 @@
+// internal/notion/encode.go
+package notion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ChildrenEncoder writes an AppendBlockChildrenRequest to a stream one block
+// at a time, producing the same indented JSON as json.MarshalIndent with a
+// two-space indent without holding every block in memory
+type ChildrenEncoder struct {
+	w      io.Writer
+	blocks int
+	err    error
+}
+
+// NewChildrenEncoder returns an encoder writing to w
+func NewChildrenEncoder(w io.Writer) *ChildrenEncoder {
+	return &ChildrenEncoder{w: w}
+}
+
+// Encode writes the next block of the request
+func (e *ChildrenEncoder) Encode(block Block) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	data, err := json.MarshalIndent(block, "    ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal block: %w", err)
+	}
+
+	separator := ",\n    "
+	if e.blocks == 0 {
+		separator = "{\n  \"children\": [\n    "
+	}
+	e.write(separator)
+	e.write(string(data))
+	e.blocks++
+	return e.err
+}
+
+// Close ends the request. It does not close the underlying writer.
+func (e *ChildrenEncoder) Close() error {
+	if e.blocks == 0 {
+		e.write("{\n  \"children\": []\n}")
+	} else {
+		e.write("\n  ]\n}")
+	}
+	return e.err
+}
+
+// write writes s unless an earlier write failed
+func (e *ChildrenEncoder) write(s string) {
+	if e.err == nil {
+		_, e.err = io.WriteString(e.w, s)
+	}
+}
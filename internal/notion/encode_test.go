@@ -0,0 +1,63 @@
+package notion
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestChildrenEncoderMatchesMarshalIndent(t *testing.T) {
+	href := "https://example.com/?a=1&b=<2>"
+	blocks := []Block{
+		{Object: "block", Type: "paragraph", Paragraph: &Paragraph{RichText: []RichText{{
+			Type: "text", Text: &Text{Content: "link"}, Href: &href,
+		}}}},
+		{Object: "block", Type: "bulleted_list_item", BulletedListItem: &BulletedListItem{
+			RichText: []RichText{{Type: "text", Text: &Text{Content: "item"}}},
+			Children: []Block{{Object: "block", Type: "divider", Divider: &Divider{}}},
+		}},
+	}
+
+	for n := 0; n <= len(blocks); n++ {
+		want, err := json.MarshalIndent(AppendBlockChildrenRequest{Children: append([]Block{}, blocks[:n]...)}, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got bytes.Buffer
+		enc := NewChildrenEncoder(&got)
+		for _, block := range blocks[:n] {
+			if err := enc.Encode(block); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.String() != string(want) {
+			t.Errorf("%d blocks: got\n%s\nwant\n%s", n, got.String(), want)
+		}
+	}
+}
+
+func TestImagePendingUploadJSON(t *testing.T) {
+	img := Image{
+		Type:    "file_upload",
+		Caption: []RichText{},
+		Pending: &FileData{Name: "diagram.png", ContentType: "image/png", Data: []byte("png")},
+	}
+	data, err := json.Marshal(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"file_upload","caption":[],"file_upload":{"pending_upload":{"name":"diagram.png","content_type":"image/png","size":3}}}`
+	if string(data) != want {
+		t.Errorf("pending image = %s, want %s", data, want)
+	}
+
+	img.FileUpload, img.Pending = &FileUploadRef{ID: "upload-1"}, nil
+	if data, _ := json.Marshal(img); !bytes.Contains(data, []byte(`"file_upload":{"id":"upload-1"}`)) {
+		t.Errorf("uploaded image = %s, want its upload ID", data)
+	}
+}
@@ -0,0 +1,127 @@
+// internal/notion/errors.go
+package notion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// APIError is an error response returned by the Notion API
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+
+	// Blocks lists the request blocks the error message points at, when the
+	// failed request carried blocks and the message names their paths
+	Blocks []FailedBlock
+	// located is set once the blocks were looked up in the failed request,
+	// so the requests that sent it as nested blocks leave them alone.
+	// nested is set while they are placed at a block of the outer request
+	// whose offset among the blocks passed to the client is not added yet.
+	located bool
+	nested  bool
+}
+
+// FailedBlock is a block rejected by the Notion API
+type FailedBlock struct {
+	// Index is the position of the top-level block among the blocks passed to the client
+	Index int
+	// Path locates the block in the request body, e.g. "body.children[3].table.children[1]"
+	Path  string
+	Block Block
+}
+
+// Error formats the API error along with the location of the rejected blocks
+func (e *APIError) Error() string {
+	var b strings.Builder
+	if e.Code != "" {
+		fmt.Fprintf(&b, "notion API error (%d): %s (code: %s)", e.StatusCode, e.Message, e.Code)
+	} else {
+		fmt.Fprintf(&b, "notion API error (%d): %s", e.StatusCode, e.Message)
+	}
+
+	for _, failed := range e.Blocks {
+		fmt.Fprintf(&b, "\n  block %d (%s)", failed.Index+1, failed.Block.Type)
+		if !failed.Block.Source.IsZero() {
+			fmt.Fprintf(&b, " from Markdown %s", failed.Block.Source)
+		}
+	}
+	return b.String()
+}
+
+// blockPathPattern matches the block paths in validation messages, such as
+// "body.children[3].bulleted_list_item.children[0].paragraph.rich_text"
+var blockPathPattern = regexp.MustCompile(`body\.children\[\d+\](?:\.\w+\.children\[\d+\]|\.children\[\d+\])*`)
+
+// childIndexPattern matches a single "children[N]" step of a block path
+var childIndexPattern = regexp.MustCompile(`children\[(\d+)\]`)
+
+// locateBlocks resolves the block paths named in the error message against
+// the request blocks, offset being the index of blocks[0] in the whole upload
+func (e *APIError) locateBlocks(blocks []Block, offset int) {
+	if e.located {
+		if e.nested {
+			for i := range e.Blocks {
+				e.Blocks[i].Index += offset
+			}
+			e.nested = false
+		}
+		return
+	}
+	e.located = true
+	seen := make(map[string]bool)
+	for _, path := range blockPathPattern.FindAllString(e.Message, -1) {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		steps := childIndexPattern.FindAllStringSubmatch(path, -1)
+		level := blocks
+		var block *Block
+		index := -1
+		for _, step := range steps {
+			i, err := strconv.Atoi(step[1])
+			if err != nil || i >= len(level) {
+				block = nil
+				break
+			}
+			if index < 0 {
+				index = i
+			}
+			block = &level[i]
+			level = block.ChildBlocks()
+		}
+		if block == nil {
+			continue
+		}
+
+		e.Blocks = append(e.Blocks, FailedBlock{
+			Index: offset + index,
+			Path:  path,
+			Block: *block,
+		})
+	}
+}
+
+// nestBlocks places the blocks located in a request appending the nested
+// blocks of blocks[block].children[child] at that block of the outer request
+func (e *APIError) nestBlocks(blocks []Block, block, child int) {
+	parent := blocks[block]
+	prefix := fmt.Sprintf("body.children[%d].%s.children[%d].%s.children",
+		block, parent.Type, child, parent.ChildBlocks()[child].Type)
+	for i := range e.Blocks {
+		// The path starts at the chunk of the failed request, the index at
+		// the nested blocks it was part of
+		rest := e.Blocks[i].Path
+		if loc := childIndexPattern.FindStringIndex(rest); loc != nil {
+			rest = rest[loc[1]:]
+		}
+		e.Blocks[i].Path = fmt.Sprintf("%s[%d]%s", prefix, e.Blocks[i].Index, rest)
+		e.Blocks[i].Index = block
+	}
+	e.located, e.nested = true, true
+}
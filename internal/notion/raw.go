@@ -0,0 +1,205 @@
+// internal/notion/raw.go
+package notion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// blockJSON has the fields of Block without its MarshalJSON method
+type blockJSON Block
+
+// MarshalJSON encodes the block, or its Raw JSON when it has one
+func (b Block) MarshalJSON() ([]byte, error) {
+	if b.Raw != nil {
+		return b.Raw, nil
+	}
+	return json.Marshal(blockJSON(b))
+}
+
+// blockTypes are the block types of the Notion API
+var blockTypes = map[string]bool{
+	"audio":              true,
+	"bookmark":           true,
+	"breadcrumb":         true,
+	"bulleted_list_item": true,
+	"callout":            true,
+	"child_database":     true,
+	"child_page":         true,
+	"code":               true,
+	"column":             true,
+	"column_list":        true,
+	"divider":            true,
+	"embed":              true,
+	"equation":           true,
+	"file":               true,
+	"heading_1":          true,
+	"heading_2":          true,
+	"heading_3":          true,
+	"image":              true,
+	"link_preview":       true,
+	"link_to_page":       true,
+	"numbered_list_item": true,
+	"paragraph":          true,
+	"pdf":                true,
+	"quote":              true,
+	"synced_block":       true,
+	"table":              true,
+	"table_of_contents":  true,
+	"table_row":          true,
+	"template":           true,
+	"to_do":              true,
+	"toggle":             true,
+	"video":              true,
+}
+
+// richTextTypes are the types of rich text elements
+var richTextTypes = map[string]bool{
+	"text":     true,
+	"mention":  true,
+	"equation": true,
+}
+
+// ParseRawBlocks parses hand-written Notion JSON, a block object or an array
+// of them, into blocks that are sent to Notion verbatim. Every block must
+// have a type of the Notion API and the matching content object, and its
+// children and rich text must be well formed; the other fields are left for
+// Notion to check.
+func ParseRawBlocks(data []byte) ([]Block, error) {
+	data = bytes.TrimSpace(data)
+	var items []json.RawMessage
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("no blocks")
+		}
+	} else {
+		items = []json.RawMessage{data}
+	}
+
+	blocks := make([]Block, 0, len(items))
+	for i, item := range items {
+		blockType, err := validateRawBlock(item)
+		if err != nil {
+			if len(items) > 1 {
+				return nil, fmt.Errorf("block %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, item); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, Block{Object: "block", Type: blockType, Raw: compact.Bytes()})
+	}
+	return blocks, nil
+}
+
+// RawBlock returns a block sending the JSON of a block parsed by
+// ParseRawBlocks verbatim
+func RawBlock(raw []byte) Block {
+	var header struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(raw, &header)
+	return Block{Object: "block", Type: header.Type, Raw: raw}
+}
+
+// validateRawBlock checks the structure of a raw block and returns its type
+func validateRawBlock(data json.RawMessage) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("a block must be a JSON object: %w", err)
+	}
+
+	var blockType string
+	if err := json.Unmarshal(fields["type"], &blockType); err != nil || blockType == "" {
+		return "", fmt.Errorf(`a block needs a "type"`)
+	}
+	if !blockTypes[blockType] {
+		return "", fmt.Errorf("unknown block type %q", blockType)
+	}
+	for name := range fields {
+		if name != "object" && name != "type" && name != blockType {
+			return "", fmt.Errorf("%s block has an unexpected %q field", blockType, name)
+		}
+	}
+
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal(fields[blockType], &content); err != nil || content == nil {
+		return "", fmt.Errorf("%s block needs a %q object", blockType, blockType)
+	}
+
+	// Nested blocks are checked on their own, as they may be of any type
+	if children, ok := content["children"]; ok {
+		var items []json.RawMessage
+		if err := json.Unmarshal(children, &items); err != nil {
+			return "", fmt.Errorf("%s children must be an array of blocks", blockType)
+		}
+		for i, item := range items {
+			if _, err := validateRawBlock(item); err != nil {
+				return "", fmt.Errorf("%s child %d: %w", blockType, i+1, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"rich_text", "caption"} {
+		if richText, ok := content[name]; ok {
+			if err := validateRichText(richText); err != nil {
+				return "", fmt.Errorf("%s %s: %w", blockType, name, err)
+			}
+		}
+	}
+	if cells, ok := content["cells"]; ok {
+		var items []json.RawMessage
+		if err := json.Unmarshal(cells, &items); err != nil {
+			return "", fmt.Errorf("%s cells must be an array of rich text arrays", blockType)
+		}
+		for i, cell := range items {
+			if err := validateRichText(cell); err != nil {
+				return "", fmt.Errorf("%s cell %d: %w", blockType, i+1, err)
+			}
+		}
+	}
+	return blockType, nil
+}
+
+// validateRichText checks that rich text is an array of elements holding
+// the object of their type. The type may be left out, as in requests to
+// the Notion API, when the element holds a single object of a known type.
+func validateRichText(data json.RawMessage) error {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("must be an array of rich text objects")
+	}
+	for i, item := range items {
+		var elementType string
+		if raw, ok := item["type"]; ok {
+			if err := json.Unmarshal(raw, &elementType); err != nil || !richTextTypes[elementType] {
+				return fmt.Errorf("element %d has an unknown type %s", i+1, raw)
+			}
+		} else {
+			for name := range richTextTypes {
+				if _, ok := item[name]; ok {
+					if elementType != "" {
+						return fmt.Errorf("element %d needs a \"type\"", i+1)
+					}
+					elementType = name
+				}
+			}
+			if elementType == "" {
+				return fmt.Errorf("element %d needs a text, mention or equation object", i+1)
+			}
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(item[elementType], &object); err != nil || object == nil {
+			return fmt.Errorf("element %d needs a %q object", i+1, elementType)
+		}
+	}
+	return nil
+}
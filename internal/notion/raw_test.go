@@ -0,0 +1,89 @@
+package notion
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseRawBlocks(t *testing.T) {
+	blocks, err := ParseRawBlocks([]byte(`[
+		{"type": "callout", "callout": {"icon": {"emoji": "💡"}, "rich_text": [{"text": {"content": "Tip"}}]}},
+		{"object": "block", "type": "paragraph", "paragraph": {"rich_text": [], "children": [
+			{"type": "synced_block", "synced_block": {"synced_from": null}}
+		]}}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseRawBlocks() error = %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Type != "callout" || blocks[1].Type != "paragraph" {
+		t.Fatalf("ParseRawBlocks() = %+v", blocks)
+	}
+
+	// Raw blocks are sent verbatim, whatever the other fields say
+	data, err := json.Marshal(AppendBlockChildrenRequest{Children: blocks[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"children":[{"type":"callout","callout":{"icon":{"emoji":"💡"},"rich_text":[{"text":{"content":"Tip"}}]}}]}`
+	if string(data) != want {
+		t.Errorf("request = %s, want %s", data, want)
+	}
+
+	// Blocks without raw JSON keep their usual encoding
+	data, err = json.Marshal(Block{Object: "block", Type: "divider", Divider: &Divider{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"object":"block","type":"divider","divider":{}}` {
+		t.Errorf("divider = %s", data)
+	}
+}
+
+func TestParseRawBlocksRichText(t *testing.T) {
+	blocks, err := ParseRawBlocks([]byte(`[
+		{"type": "paragraph", "paragraph": {"rich_text": [
+			{"type": "mention", "mention": {"type": "page", "page": {"id": "59833787-2cf9-4fdf-8782-e53db20768a5"}}},
+			{"mention": {"type": "date", "date": {"start": "2024-01-01"}}},
+			{"type": "equation", "equation": {"expression": "e = mc^2"}, "annotations": {"bold": true}}
+		]}},
+		{"type": "heading_2", "heading_2": {"rich_text": [{"equation": {"expression": "x"}}], "is_toggleable": true, "children": [
+			{"type": "table_row", "table_row": {"cells": [[{"type": "text", "text": {"content": "a"}}]]}}
+		]}}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseRawBlocks() error = %v", err)
+	}
+	if len(blocks) != 2 || blocks[1].Type != "heading_2" {
+		t.Errorf("ParseRawBlocks() = %+v", blocks)
+	}
+}
+
+func TestParseRawBlocksErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"not json", `{"type":`, "unexpected end"},
+		{"no type", `{"paragraph": {}}`, `a block needs a "type"`},
+		{"no content", `{"type": "embed"}`, `embed block needs a "embed" object`},
+		{"misspelt content", `{"type": "paragraph", "paragrah": {}}`, `unexpected "paragrah" field`},
+		{"unknown type", `{"type": "foo", "foo": {}}`, `unknown block type "foo"`},
+		{"rich text not an array", `{"type": "code", "code": {"rich_text": {"text": {"content": "x"}}}}`, "code rich_text: must be an array"},
+		{"rich text type", `{"type": "paragraph", "paragraph": {"rich_text": [{"type": "link", "link": {}}]}}`, `element 1 has an unknown type "link"`},
+		{"rich text object", `{"type": "quote", "quote": {"rich_text": [{"type": "mention"}]}}`, `element 1 needs a "mention" object`},
+		{"caption", `{"type": "image", "image": {"caption": [{"content": "x"}]}}`, "image caption: element 1 needs a text, mention or equation object"},
+		{"cells", `{"type": "table_row", "table_row": {"cells": [[], [{}]]}}`, "table_row cell 2: element 1"},
+		{"invalid child", `[{"type": "divider", "divider": {}}, {"type": "toggle", "toggle": {"children": [{}]}}]`, "block 2: toggle child 1:"},
+		{"empty array", `[]`, "no blocks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRawBlocks([]byte(tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseRawBlocks() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 // internal/notion/types.go
 package notion
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Block represents a Notion block structure
 type Block struct {
@@ -18,9 +22,141 @@ type Block struct {
 	Image            *Image            `json:"image,omitempty"`
 	BulletedListItem *BulletedListItem `json:"bulleted_list_item,omitempty"`
 	NumberedListItem *NumberedListItem `json:"numbered_list_item,omitempty"`
+	Toggle           *Toggle           `json:"toggle,omitempty"`
+	Callout          *Callout          `json:"callout,omitempty"`
+	ToDo             *ToDo             `json:"to_do,omitempty"`
 	Table            *Table            `json:"table,omitempty"`
 	TableRow         *TableRow         `json:"table_row,omitempty"`
 	Children         []Block           `json:"children,omitempty"`
+
+	// Anchor names the block as the target of intra-page "#anchor" links.
+	// It is never sent to Notion; links are resolved once the block has an ID.
+	Anchor string `json:"-"`
+	// Source is the range of Markdown lines the block was converted from.
+	// It is never sent to Notion; it locates blocks rejected by the API.
+	Source SourceRange `json:"-"`
+	// Raw is hand-written JSON sent to Notion instead of the fields above
+	Raw json.RawMessage `json:"-"`
+}
+
+// SourceRange is an inclusive range of 1-based source lines
+type SourceRange struct {
+	StartLine int
+	EndLine   int
+}
+
+// IsZero reports whether the range is unknown
+func (r SourceRange) IsZero() bool {
+	return r.StartLine == 0
+}
+
+// String formats the range as "line N" or "lines N-M"
+func (r SourceRange) String() string {
+	if r.EndLine <= r.StartLine {
+		return fmt.Sprintf("line %d", r.StartLine)
+	}
+	return fmt.Sprintf("lines %d-%d", r.StartLine, r.EndLine)
+}
+
+// ChildBlocks returns the nested blocks carried by the block's type payload
+func (b *Block) ChildBlocks() []Block {
+	switch {
+	case b.BulletedListItem != nil:
+		return b.BulletedListItem.Children
+	case b.NumberedListItem != nil:
+		return b.NumberedListItem.Children
+	case b.Paragraph != nil:
+		return b.Paragraph.Children
+	case b.Toggle != nil:
+		return b.Toggle.Children
+	case b.Callout != nil:
+		return b.Callout.Children
+	case b.ToDo != nil:
+		return b.ToDo.Children
+	case b.Table != nil:
+		return b.Table.Children
+	}
+	return b.Children
+}
+
+// withChildBlocks returns a copy of the block carrying children as its
+// nested blocks
+func (b Block) withChildBlocks(children []Block) Block {
+	switch {
+	case b.BulletedListItem != nil:
+		item := *b.BulletedListItem
+		item.Children = children
+		b.BulletedListItem = &item
+	case b.NumberedListItem != nil:
+		item := *b.NumberedListItem
+		item.Children = children
+		b.NumberedListItem = &item
+	case b.Paragraph != nil:
+		paragraph := *b.Paragraph
+		paragraph.Children = children
+		b.Paragraph = &paragraph
+	case b.Toggle != nil:
+		toggle := *b.Toggle
+		toggle.Children = children
+		b.Toggle = &toggle
+	case b.Callout != nil:
+		callout := *b.Callout
+		callout.Children = children
+		b.Callout = &callout
+	case b.ToDo != nil:
+		item := *b.ToDo
+		item.Children = children
+		b.ToDo = &item
+	case b.Table != nil:
+		table := *b.Table
+		table.Children = children
+		b.Table = &table
+	default:
+		b.Children = children
+	}
+	return b
+}
+
+// EachRichText calls fn for every rich text element of the block's own
+// content, including table cells and captions but not nested blocks
+func (b *Block) EachRichText(fn func(rt *RichText)) {
+	var lists []*[]RichText
+	switch {
+	case b.Paragraph != nil:
+		lists = append(lists, &b.Paragraph.RichText)
+	case b.Heading1 != nil:
+		lists = append(lists, &b.Heading1.RichText)
+	case b.Heading2 != nil:
+		lists = append(lists, &b.Heading2.RichText)
+	case b.Heading3 != nil:
+		lists = append(lists, &b.Heading3.RichText)
+	case b.Code != nil:
+		lists = append(lists, &b.Code.RichText, &b.Code.Caption)
+	case b.Quote != nil:
+		lists = append(lists, &b.Quote.RichText)
+	case b.Image != nil:
+		lists = append(lists, &b.Image.Caption)
+	case b.BulletedListItem != nil:
+		lists = append(lists, &b.BulletedListItem.RichText)
+	case b.NumberedListItem != nil:
+		lists = append(lists, &b.NumberedListItem.RichText)
+	case b.Toggle != nil:
+		lists = append(lists, &b.Toggle.RichText)
+	case b.Callout != nil:
+		lists = append(lists, &b.Callout.RichText)
+	case b.ToDo != nil:
+		lists = append(lists, &b.ToDo.RichText)
+	case b.TableRow != nil:
+		for i := range b.TableRow.Cells {
+			lists = append(lists, &b.TableRow.Cells[i])
+		}
+	}
+
+	for _, list := range lists {
+		for i := range *list {
+			fn(&(*list)[i])
+		}
+	}
 }
 
 // RichText represents formatted text content
@@ -56,6 +192,7 @@ type Annotations struct {
 type Paragraph struct {
 	RichText []RichText `json:"rich_text"`
 	Color    string     `json:"color,omitempty"`
+	Children []Block    `json:"children,omitempty"`
 }
 
 // Heading block type (shared for h1, h2, h3)
@@ -82,9 +219,70 @@ type Divider struct{}
 
 // Image block type
 type Image struct {
-	Type     string     `json:"type"`
-	External *External  `json:"external,omitempty"`
-	Caption  []RichText `json:"caption"`
+	Type       string         `json:"type"`
+	External   *External      `json:"external,omitempty"`
+	FileUpload *FileUploadRef `json:"file_upload,omitempty"`
+	Caption    []RichText     `json:"caption"`
+
+	// Pending holds the file to upload for a "file_upload" image whose
+	// upload has not been created yet. It is never sent to Notion.
+	Pending *FileData `json:"-"`
+}
+
+// pendingUpload stands in for the file upload of a Pending image in JSON
+// output, such as dry runs, where no upload exists yet
+type pendingUpload struct {
+	Pending struct {
+		Name        string `json:"name"`
+		ContentType string `json:"content_type"`
+		Size        int    `json:"size"`
+	} `json:"pending_upload"`
+}
+
+// MarshalJSON encodes the image. An image whose file is not uploaded yet has
+// a file_upload object without an ID, marked as a pending upload.
+func (img Image) MarshalJSON() ([]byte, error) {
+	type image Image
+	if img.Pending == nil || img.FileUpload != nil {
+		return json.Marshal(image(img))
+	}
+	out := struct {
+		image
+		FileUpload pendingUpload `json:"file_upload"`
+	}{image: image(img)}
+	out.FileUpload.Pending.Name = img.Pending.Name
+	out.FileUpload.Pending.ContentType = img.Pending.ContentType
+	out.FileUpload.Pending.Size = len(img.Pending.Data)
+	return json.Marshal(out)
+}
+
+// FileUploadRef references a completed file upload from a block
+type FileUploadRef struct {
+	ID string `json:"id"`
+}
+
+// FileData is the content of a file waiting to be uploaded
+type FileData struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// CreateFileUploadRequest is the request body for starting a file upload
+type CreateFileUploadRequest struct {
+	Mode        string `json:"mode"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+}
+
+// FileUpload represents a file upload object
+type FileUpload struct {
+	Object      string `json:"object"`
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	UploadURL   string `json:"upload_url"`
 }
 
 // External represents an external image URL
@@ -106,6 +304,29 @@ type NumberedListItem struct {
 	Children []Block    `json:"children,omitempty"`
 }
 
+// Toggle is a block whose children are hidden until it is expanded
+type Toggle struct {
+	RichText []RichText `json:"rich_text"`
+	Color    string     `json:"color,omitempty"`
+	Children []Block    `json:"children,omitempty"`
+}
+
+// Callout is a block of text set off with an icon and a background color
+type Callout struct {
+	RichText []RichText `json:"rich_text"`
+	Icon     *Icon      `json:"icon,omitempty"`
+	Color    string     `json:"color,omitempty"`
+	Children []Block    `json:"children,omitempty"`
+}
+
+// ToDo is a to-do list item with a checkbox
+type ToDo struct {
+	RichText []RichText `json:"rich_text"`
+	Checked  bool       `json:"checked"`
+	Color    string     `json:"color,omitempty"`
+	Children []Block    `json:"children,omitempty"`
+}
+
 // AppendBlockChildrenRequest is the request body for appending blocks
 type AppendBlockChildrenRequest struct {
 	Children []Block `json:"children"`
@@ -114,10 +335,33 @@ type AppendBlockChildrenRequest struct {
 // CreatePageRequest is the request body for creating a new page
 type CreatePageRequest struct {
 	Parent     Parent         `json:"parent"`
+	Icon       *Icon          `json:"icon,omitempty"`
 	Properties PageProperties `json:"properties"`
 	Children   []Block        `json:"children,omitempty"`
 }
 
+// UpdatePageRequest is the request body for updating page metadata
+type UpdatePageRequest struct {
+	Icon       *Icon                    `json:"icon,omitempty"`
+	Properties map[string]PropertyValue `json:"properties,omitempty"`
+}
+
+// PropertyValue is the value of a database page property
+type PropertyValue struct {
+	MultiSelect []SelectOption `json:"multi_select"`
+}
+
+// SelectOption is an option of a select or multi-select property
+type SelectOption struct {
+	Name string `json:"name"`
+}
+
+// Icon represents a page icon
+type Icon struct {
+	Type  string `json:"type"`
+	Emoji string `json:"emoji,omitempty"`
+}
+
 // Parent specifies the parent of a page
 type Parent struct {
 	Type       string `json:"type"`
@@ -171,6 +415,7 @@ type PageResponse struct {
 	CreatedTime    time.Time      `json:"created_time"`
 	LastEditedTime time.Time      `json:"last_edited_time"`
 	URL            string         `json:"url"`
+	Icon           *Icon          `json:"icon,omitempty"`
 	Parent         Parent         `json:"parent"`
 	Properties     PageProperties `json:"properties"`
 }
@@ -0,0 +1,98 @@
+// internal/notion/uploads.go
+package notion
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+// MaxSinglePartUploadSize is the largest file Notion accepts in a single-part upload
+const MaxSinglePartUploadSize = 20 << 20
+
+// UploadFile uploads a file to Notion and returns the file upload ID to
+// reference from blocks. Files with identical content are uploaded once per client.
+func (c *Client) UploadFile(ctx context.Context, file FileData) (string, error) {
+	if len(file.Data) > MaxSinglePartUploadSize {
+		return "", fmt.Errorf("file %s is %d bytes, larger than the %d bytes upload limit",
+			file.Name, len(file.Data), MaxSinglePartUploadSize)
+	}
+
+	sum := sha256.Sum256(file.Data)
+	hash := hex.EncodeToString(sum[:])
+
+	c.uploadsMu.Lock()
+	id, ok := c.uploads[hash]
+	c.uploadsMu.Unlock()
+	if ok {
+		if c.verbose {
+			fmt.Fprintf(os.Stderr, "Reusing upload %s for %s\n", id, file.Name)
+		}
+		return id, nil
+	}
+
+	// Create the upload, then send its content
+	req := CreateFileUploadRequest{
+		Mode:        "single_part",
+		Filename:    file.Name,
+		ContentType: file.ContentType,
+	}
+	var upload FileUpload
+	if err := c.makeRequest(ctx, "POST", "/file_uploads", req, &upload); err != nil {
+		return "", fmt.Errorf("failed to create file upload: %w", err)
+	}
+
+	body, contentType, err := multipartFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", file.Name, err)
+	}
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "Uploading %s (%d bytes)\n", file.Name, len(file.Data))
+	}
+	var sent FileUpload
+	if err := c.doRequest(ctx, "POST", fmt.Sprintf("/file_uploads/%s/send", upload.ID), contentType, body, &sent); err != nil {
+		return "", fmt.Errorf("failed to send file %s: %w", file.Name, err)
+	}
+	if sent.Status != "" && sent.Status != "uploaded" {
+		return "", fmt.Errorf("upload of %s ended with status %q", file.Name, sent.Status)
+	}
+
+	c.uploadsMu.Lock()
+	if c.uploads == nil {
+		c.uploads = make(map[string]string)
+	}
+	c.uploads[hash] = upload.ID
+	c.uploadsMu.Unlock()
+
+	return upload.ID, nil
+}
+
+// multipartFile encodes a file as the multipart form expected by the send endpoint
+func multipartFile(file FileData) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`,
+		strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(file.Name)))
+	header.Set("Content-Type", file.ContentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(file.Data); err != nil {
+		return nil, "", err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
@@ -0,0 +1,496 @@
+// internal/preprocess/include.go
+package preprocess
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IncludeOptions configures include expansion
+type IncludeOptions struct {
+	// Path is the path of the document, empty for stdin. Included files are
+	// resolved against the directory of the file including them, and their
+	// relative links and images rebased onto the directory of Path.
+	Path string
+	// Markers selects the content of included files with their marker
+	// comments when set, as SelectMarked does
+	Markers *MarkerOptions
+	// Lines records the file and line each line of the expanded document
+	// comes from when set
+	Lines *LineMap
+}
+
+// IncludeError is an include directive that could not be expanded
+type IncludeError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *IncludeError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// commentInclude matches an <!-- include: path shift=1 --> directive
+	commentInclude = regexp.MustCompile(`^ {0,3}<!--\s*include:\s*(.*?)\s*-->\s*$`)
+	// shortcodeInclude matches a {{< include "path" shift=1 >}} directive
+	shortcodeInclude = regexp.MustCompile(`^ {0,3}\{\{<\s*include\s+(.*?)\s*>\}\}\s*$`)
+
+	fenceOpen       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	atxHeading      = regexp.MustCompile(`^( {0,3})(#{1,6})([ \t]|\r?\n|$)`)
+	setextUnderline = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*\r?\n?$`)
+	// notParagraph matches lines that start a block other than a paragraph
+	notParagraph = regexp.MustCompile(`^( {4}|\t| {0,3}([-*+>#<|]|\d{1,9}[.)]))`)
+
+	// Link destinations: inline links and images, reference definitions
+	// and HTML attributes
+	inlineDestination    = regexp.MustCompile(`\]\(\s*(<[^>\n]*>|[^\s)]+)`)
+	referenceDestination = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*(<[^>\n]*>|\S+)`)
+	htmlDestination      = regexp.MustCompile(`\b(?:src|href)\s*=\s*"([^"]*)"`)
+	urlScheme            = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// Include expands the include directives of content, on lines of their own
+// outside code blocks:
+//
+//	<!-- include: sections/faq.md shift=1 -->
+//	{{< include "sections/faq.md" shift=1 >}}
+//
+// shift moves the headings of the included file down (or up when negative)
+// by that many levels. Included files may include other files; an include
+// cycle is an error. CSV and TSV files are included as ```csv and ```tsv
+// code blocks, which convert to tables; header=true or header=false sets
+// whether their first row is the column header.
+func Include(content []byte, opts IncludeOptions) ([]byte, error) {
+	var out bytes.Buffer
+	if err := newIncluder(opts).expand(&out, bytes.NewReader(content), opts.Path, 0, 0); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// IncludeReader expands include directives like Include while reading r
+func IncludeReader(r io.Reader, opts IncludeOptions) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(pw)
+		err := newIncluder(opts).expand(w, r, opts.Path, 0, 0)
+		if err == nil {
+			err = w.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// includer expands include directives recursively
+type includer struct {
+	// root is the directory relative paths are rebased onto
+	root    string
+	markers *MarkerOptions
+	// stack holds the absolute paths of the files being expanded
+	stack []string
+	lines *LineMap
+}
+
+func newIncluder(opts IncludeOptions) *includer {
+	inc := &includer{root: ".", markers: opts.Markers, lines: opts.Lines}
+	if opts.Path != "" {
+		inc.root = filepath.Dir(opts.Path)
+		if abs, err := filepath.Abs(opts.Path); err == nil {
+			inc.stack = []string{abs}
+		}
+	}
+	return inc
+}
+
+// expand copies the file at path, read from r, to w with its directives
+// expanded and its headings shifted by shift levels. The content of r
+// starts after the first skipped lines of the file.
+func (inc *includer) expand(w io.Writer, r io.Reader, path string, shift, skipped int) error {
+	name := path
+	dir := inc.root
+	if path == "" {
+		name = "<stdin>"
+	} else {
+		dir = filepath.Dir(path)
+	}
+
+	br := bufio.NewReader(r)
+	var fence string
+	// pending holds a paragraph line that a setext underline may turn into
+	// a heading to shift
+	var pending []byte
+	var pendingLine int
+	afterBlank := true
+	lineNumber := skipped
+
+	// write writes a line read from the given line of the file
+	write := func(line []byte, source int) error {
+		inc.lines.add(name, source)
+		_, err := w.Write(line)
+		return err
+	}
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		line := pending
+		pending = nil
+		return write(line, pendingLine)
+	}
+
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		if len(line) == 0 {
+			break
+		}
+		lineNumber++
+
+		if fence != "" {
+			if isFenceClose(line, fence) {
+				fence = ""
+			}
+			if err := write(line, lineNumber); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if pending != nil && setextUnderline.Match(line) {
+			level := 2
+			if bytes.Contains(line, []byte("=")) {
+				level = 1
+			}
+			heading := strings.Repeat("#", clampLevel(level+shift)) + " " + string(bytes.TrimSpace(pending)) + "\n"
+			pending = nil
+			afterBlank = false
+			if err := write([]byte(heading), pendingLine); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		if m := fenceOpen.FindSubmatch(line); m != nil {
+			fence = string(m[1])
+			afterBlank = false
+			if err := write(line, lineNumber); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if directive, ok, err := parseInclude(line); ok || err != nil {
+			if err == nil {
+				directive.shift += shift
+				err = inc.include(w, name, lineNumber, dir, directive)
+			}
+			if err != nil {
+				var includeErr *IncludeError
+				if errors.As(err, &includeErr) {
+					return err
+				}
+				return &IncludeError{File: name, Line: lineNumber, Err: err}
+			}
+			afterBlank = true
+			continue
+		}
+
+		line = inc.rebase(line, dir)
+		blank := len(bytes.TrimSpace(line)) == 0
+		switch {
+		case shift != 0 && atxHeading.Match(line):
+			line = shiftHeading(line, shift)
+		case shift != 0 && afterBlank && !blank && !notParagraph.Match(line):
+			pending, pendingLine = line, lineNumber
+			afterBlank = false
+			continue
+		}
+		afterBlank = blank
+		if err := write(line, lineNumber); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+// include expands the file a directive on the given line of the file name,
+// in dir, includes
+func (inc *includer) include(w io.Writer, name string, line int, dir string, directive includeDirective) error {
+	path := directive.target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for i, parent := range inc.stack {
+		if parent == abs {
+			cycle := append(append([]string(nil), inc.stack[i:]...), abs)
+			return fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to include %s: %w", directive.target, err)
+	}
+	if format, ok := dataFormats[strings.ToLower(filepath.Ext(path))]; ok {
+		// The fences stand for the directive
+		inc.lines.add(name, line)
+		for i := range bytes.Count(bytes.TrimSuffix(content, []byte("\n")), []byte("\n")) + 1 {
+			inc.lines.add(path, i+1)
+		}
+		inc.lines.add(name, line)
+		return writeDataFence(w, content, format, directive.header)
+	}
+
+	body, _, err := SplitFrontMatter(content)
+	if err != nil {
+		return &IncludeError{File: path, Line: 1, Err: err}
+	}
+	if inc.markers != nil {
+		opts := *inc.markers
+		opts.Name = path
+		if body, err = SelectMarked(body, opts); err != nil {
+			return err
+		}
+	}
+	trimmed := bytes.TrimLeft(body, "\n")
+	skipped := len(body) - len(trimmed)
+	body = trimmed
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		body = append(body, '\n')
+	}
+
+	inc.stack = append(inc.stack, abs)
+	defer func() { inc.stack = inc.stack[:len(inc.stack)-1] }()
+	return inc.expand(w, bytes.NewReader(body), path, directive.shift, skipped)
+}
+
+// LineMap locates the lines of a document expanded by Include in the files
+// they come from
+type LineMap struct {
+	// runs holds the runs of consecutive lines from the same file
+	runs  []lineRun
+	lines int
+}
+
+// lineRun is a run of lines starting at line of the expanded document,
+// read from source on in file
+type lineRun struct {
+	line   int
+	file   string
+	source int
+}
+
+// add records the file and line the next line of the document comes from
+func (m *LineMap) add(file string, source int) {
+	if m == nil {
+		return
+	}
+	m.lines++
+	if n := len(m.runs); n > 0 {
+		last := m.runs[n-1]
+		if last.file == file && last.source+m.lines-last.line == source {
+			return
+		}
+	}
+	m.runs = append(m.runs, lineRun{line: m.lines, file: file, source: source})
+}
+
+// Locate returns the file and line a line of the expanded document comes
+// from. ok is false for lines the map does not hold.
+func (m *LineMap) Locate(line int) (file string, source int, ok bool) {
+	if m == nil || line < 1 || line > m.lines {
+		return "", 0, false
+	}
+	i := sort.Search(len(m.runs), func(i int) bool { return m.runs[i].line > line }) - 1
+	run := m.runs[i]
+	return run.file, run.source + line - run.line, true
+}
+
+// dataFormats maps the extensions of included data files to the language
+// of the fenced code block they are included as, converted to a table
+var dataFormats = map[string]string{
+	".csv": "csv",
+	".tsv": "tsv",
+}
+
+// writeDataFence writes data as a fenced code block of the given format,
+// with a fence longer than any backtick run in the data
+func writeDataFence(w io.Writer, data []byte, format, header string) error {
+	longest, run := 0, 0
+	for _, b := range data {
+		if b == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	info := format
+	if header != "" {
+		info += " header=" + header
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	_, err := fmt.Fprintf(w, "%s%s\n%s%s\n", fence, info, data, fence)
+	return err
+}
+
+// includeDirective is a parsed include directive
+type includeDirective struct {
+	target string
+	// shift moves the headings of an included Markdown file
+	shift int
+	// header sets whether the first row of an included CSV file is its
+	// header, guessed when empty
+	header string
+}
+
+// parseInclude parses an include directive line
+func parseInclude(line []byte) (includeDirective, bool, error) {
+	var args string
+	if m := commentInclude.FindSubmatch(line); m != nil {
+		args = string(m[1])
+	} else if m := shortcodeInclude.FindSubmatch(line); m != nil {
+		args = string(m[1])
+	} else {
+		return includeDirective{}, false, nil
+	}
+
+	var directive includeDirective
+	rest := ""
+	if strings.HasPrefix(args, `"`) {
+		end := strings.Index(args[1:], `"`)
+		if end < 0 {
+			return directive, false, fmt.Errorf("unterminated include path %s", args)
+		}
+		directive.target, rest = args[1:end+1], args[end+2:]
+	} else if i := strings.IndexAny(args, " \t"); i >= 0 {
+		directive.target, rest = args[:i], args[i:]
+	} else {
+		directive.target = args
+	}
+	if directive.target == "" {
+		return directive, false, fmt.Errorf("include directive without a path")
+	}
+
+	_, isData := dataFormats[strings.ToLower(filepath.Ext(directive.target))]
+	for _, option := range strings.Fields(rest) {
+		name, value, _ := strings.Cut(option, "=")
+		switch {
+		case name == "shift" && !isData:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return directive, false, fmt.Errorf("invalid include shift %q", value)
+			}
+			directive.shift = n
+		case name == "header" && isData:
+			if value != "true" && value != "false" {
+				return directive, false, fmt.Errorf("invalid include header %q, expected true or false", value)
+			}
+			directive.header = value
+		default:
+			return directive, false, fmt.Errorf("unknown include option %q", option)
+		}
+	}
+	return directive, true, nil
+}
+
+// isFenceClose reports whether line closes a code block opened by fence
+func isFenceClose(line []byte, fence string) bool {
+	trimmed := bytes.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || !bytes.HasPrefix(trimmed, []byte(fence)) {
+		return false
+	}
+	return len(bytes.TrimSpace(bytes.TrimLeft(trimmed, fence[:1]))) == 0
+}
+
+// shiftHeading moves an ATX heading by shift levels
+func shiftHeading(line []byte, shift int) []byte {
+	m := atxHeading.FindSubmatchIndex(line)
+	level := clampLevel(m[5] - m[4] + shift)
+	shifted := append([]byte(nil), line[:m[4]]...)
+	shifted = append(shifted, strings.Repeat("#", level)...)
+	return append(shifted, line[m[5]:]...)
+}
+
+// clampLevel keeps a heading level within Markdown's six levels
+func clampLevel(level int) int {
+	return min(max(level, 1), 6)
+}
+
+// rebase rewrites the relative link and image destinations of a line from
+// an included file in dir so they resolve from the root document
+func (inc *includer) rebase(line []byte, dir string) []byte {
+	if filepath.Clean(dir) == filepath.Clean(inc.root) {
+		return line
+	}
+	rewrite := func(pattern *regexp.Regexp, line []byte) []byte {
+		var out []byte
+		last := 0
+		for _, m := range pattern.FindAllSubmatchIndex(line, -1) {
+			out = append(out, line[last:m[2]]...)
+			out = append(out, inc.rebasePath(string(line[m[2]:m[3]]), dir)...)
+			last = m[3]
+		}
+		if out == nil {
+			return line
+		}
+		return append(out, line[last:]...)
+	}
+	line = rewrite(inlineDestination, line)
+	line = rewrite(referenceDestination, line)
+	return rewrite(htmlDestination, line)
+}
+
+// rebasePath rebases a single link destination
+func (inc *includer) rebasePath(destination, dir string) string {
+	path, angled := destination, false
+	if strings.HasPrefix(path, "<") && strings.HasSuffix(path, ">") {
+		path, angled = path[1:len(path)-1], true
+	}
+	if path == "" || strings.HasPrefix(path, "#") || strings.HasPrefix(path, "/") || urlScheme.MatchString(path) {
+		return destination
+	}
+
+	suffix := ""
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path, suffix = path[:i], path[i:]
+	}
+	rebased, err := filepath.Rel(inc.root, filepath.Join(dir, filepath.FromSlash(path)))
+	if err != nil {
+		return destination
+	}
+	rebased = filepath.ToSlash(rebased) + suffix
+	if angled {
+		return "<" + rebased + ">"
+	}
+	return rebased
+}
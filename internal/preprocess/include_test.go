@@ -0,0 +1,130 @@
+package preprocess
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files under a temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"docs/main.md": "# Guide\n\n<!-- include: sections/faq.md shift=1 -->\n\n" +
+			"```markdown\n<!-- include: sections/faq.md -->\n```\n",
+		"docs/sections/faq.md": "---\ntitle: FAQ\n---\n# FAQ\n\nWhy\n===\n\n" +
+			"![Diagram](img/flow.png) see [setup](../setup.md#install) or [site](https://example.com).\n\n" +
+			"{{< include \"answers.md\" shift=1 >}}\n\n[ref]: ./notes.md\n",
+		"docs/sections/answers.md": "## Answer\n\nBecause.\n",
+	})
+
+	main := filepath.Join(dir, "docs", "main.md")
+	content, err := os.ReadFile(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Include(content, IncludeOptions{Path: main})
+	if err != nil {
+		t.Fatalf("Include() error = %v", err)
+	}
+
+	want := "# Guide\n\n" +
+		"## FAQ\n\n## Why\n\n" +
+		"![Diagram](sections/img/flow.png) see [setup](setup.md#install) or [site](https://example.com).\n\n" +
+		"#### Answer\n\nBecause.\n\n" +
+		"[ref]: sections/notes.md\n\n" +
+		"```markdown\n<!-- include: sections/faq.md -->\n```\n"
+	if string(got) != want {
+		t.Errorf("Include() = %q, want %q", got, want)
+	}
+
+	streamed, err := io.ReadAll(IncludeReader(strings.NewReader(string(content)), IncludeOptions{Path: main}))
+	if err != nil {
+		t.Fatalf("IncludeReader() error = %v", err)
+	}
+	if string(streamed) != want {
+		t.Errorf("IncludeReader() = %q, want %q", streamed, want)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.md":    "# Main\n\n<!-- include: a.md -->\n",
+		"a.md":       "A\n\n<!-- include: b.md -->\n",
+		"b.md":       "B\n<!-- include: main.md -->\n",
+		"missing.md": "Text\n\n{{< include \"nope.md\" >}}\n",
+		"option.md":  "<!-- include: a.md level=2 -->\n",
+	})
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"main.md", "b.md:2: include cycle: "},
+		{"missing.md", "missing.md:3: failed to include nope.md"},
+		{"option.md", `option.md:1: unknown include option "level=2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Include(content, IncludeOptions{Path: path})
+			var includeErr *IncludeError
+			if !errors.As(err, &includeErr) {
+				t.Fatalf("Include() error = %v, want an IncludeError", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Include() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeData(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"report.md":        "# Metrics\n\n<!-- include: data/latency.csv header=false -->\n{{< include \"data/users.tsv\" >}}\n",
+		"data/latency.csv": "billing,120\napi,\"8`5```\"",
+		"data/users.tsv":   "region\tusers\n",
+	})
+
+	path := filepath.Join(dir, "report.md")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Include(content, IncludeOptions{Path: path})
+	if err != nil {
+		t.Fatalf("Include() error = %v", err)
+	}
+
+	want := "# Metrics\n\n" +
+		"````csv header=false\nbilling,120\napi,\"8`5```\"\n````\n" +
+		"```tsv\nregion\tusers\n```\n"
+	if string(got) != want {
+		t.Errorf("Include() = %q, want %q", got, want)
+	}
+
+	if _, err := Include([]byte("<!-- include: data/latency.csv shift=1 -->\n"), IncludeOptions{Path: path}); err == nil {
+		t.Error("Include() accepted a shift for a CSV file")
+	}
+}
@@ -0,0 +1,198 @@
+// internal/preprocess/markers.go
+package preprocess
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MarkerOptions configures the selection of content by marker comments
+type MarkerOptions struct {
+	// Name names the document in errors, usually its path
+	Name string
+	// Profiles are the active destination profiles
+	Profiles []string
+}
+
+var (
+	// markerComment matches a <!-- notion:skip profile=a,b --> marker. The
+	// closing "-->" is missing when a notion:only marker comments out the
+	// content it wraps.
+	markerComment = regexp.MustCompile(`^ {0,3}<!--\s*notion:([a-z]+)\b(.*?)\s*(-->)?\s*$`)
+	// commentEnd matches the line closing a commented-out notion:only region
+	commentEnd = regexp.MustCompile(`^\s*-->\s*$`)
+)
+
+// region is a marked region being read
+type region struct {
+	kind string
+	line int
+	// keep tells whether the content of the region is kept
+	keep bool
+	// commented is set for a notion:only region inside an HTML comment,
+	// which ends at the closing "-->"
+	commented bool
+}
+
+// SelectMarked selects the content meant for Notion with marker comments,
+// on lines of their own outside code blocks:
+//
+//	<!-- notion:skip -->    content left out of Notion  <!-- notion:end -->
+//	<!-- notion:only -->    content only for Notion     <!-- notion:end -->
+//	<!-- notion:only
+//	content hidden elsewhere, such as on GitHub, but published to Notion
+//	-->
+//
+// A profile=a,b attribute restricts a marker to some destination profiles:
+// notion:skip then only skips content for those profiles, and notion:only
+// only keeps it for them. Markers and content left out are replaced with
+// blank lines, so line numbers still match the source.
+func SelectMarked(content []byte, opts MarkerOptions) ([]byte, error) {
+	var out bytes.Buffer
+	if err := selectMarked(&out, bytes.NewReader(content), opts); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// SelectMarkedReader selects marked content like SelectMarked while
+// reading r
+func SelectMarkedReader(r io.Reader, opts MarkerOptions) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(pw)
+		err := selectMarked(w, r, opts)
+		if err == nil {
+			err = w.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// selectMarked copies the content of r selected by its markers to w
+func selectMarked(w io.Writer, r io.Reader, opts MarkerOptions) error {
+	name := opts.Name
+	if name == "" {
+		name = "<stdin>"
+	}
+
+	br := bufio.NewReader(r)
+	var regions []region
+	var fence string
+	lineNumber := 0
+
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		if len(line) == 0 {
+			break
+		}
+		lineNumber++
+
+		keep := len(regions) == 0 || regions[len(regions)-1].keep
+		marker := false
+		switch {
+		case len(regions) > 0 && regions[len(regions)-1].commented:
+			if commentEnd.Match(line) {
+				regions = regions[:len(regions)-1]
+				marker = true
+			}
+
+		case fence != "":
+			if isFenceClose(line, fence) {
+				fence = ""
+			}
+
+		default:
+			if m := fenceOpen.FindSubmatch(line); m != nil {
+				fence = string(m[1])
+				break
+			}
+			m := markerComment.FindSubmatch(line)
+			if m == nil {
+				break
+			}
+			marker = true
+
+			kind, args, closed := string(m[1]), string(m[2]), len(m[3]) > 0
+			if kind == "end" {
+				if len(regions) == 0 {
+					return fmt.Errorf("%s:%d: notion:end without a notion:skip or notion:only marker", name, lineNumber)
+				}
+				regions = regions[:len(regions)-1]
+				break
+			}
+
+			started, err := newRegion(kind, args, closed, opts.Profiles)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", name, lineNumber, err)
+			}
+			started.line = lineNumber
+			started.keep = started.keep && keep
+			regions = append(regions, started)
+		}
+
+		if marker || !keep {
+			// Keep the line break so line numbers still match
+			line = lineBreak(line)
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+
+	if len(regions) > 0 {
+		open := regions[len(regions)-1]
+		if open.commented {
+			return fmt.Errorf(`%s:%d: notion:%s comment is never closed with "-->"`, name, open.line, open.kind)
+		}
+		return fmt.Errorf("%s:%d: notion:%s is never closed with notion:end", name, open.line, open.kind)
+	}
+	return nil
+}
+
+// newRegion starts the region of a marker with the given attributes
+func newRegion(kind, args string, closed bool, profiles []string) (region, error) {
+	var targets []string
+	for _, attribute := range strings.Fields(args) {
+		name, value, _ := strings.Cut(attribute, "=")
+		if name != "profile" || value == "" {
+			return region{}, fmt.Errorf("unknown notion:%s attribute %q", kind, attribute)
+		}
+		targets = append(targets, strings.Split(value, ",")...)
+	}
+	targeted := len(targets) == 0 || slices.ContainsFunc(targets, func(target string) bool {
+		return slices.Contains(profiles, target)
+	})
+
+	switch kind {
+	case "skip":
+		if !closed {
+			return region{}, fmt.Errorf(`notion:skip marker must end with "-->"`)
+		}
+		return region{kind: kind, keep: !targeted}, nil
+	case "only":
+		return region{kind: kind, keep: targeted, commented: !closed}, nil
+	}
+	return region{}, fmt.Errorf("unknown marker notion:%s", kind)
+}
+
+// lineBreak returns the line break ending line
+func lineBreak(line []byte) []byte {
+	switch {
+	case bytes.HasSuffix(line, []byte("\r\n")):
+		return []byte("\r\n")
+	case bytes.HasSuffix(line, []byte("\n")):
+		return []byte("\n")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package preprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectMarked(t *testing.T) {
+	content := "# Project\n" +
+		"<!-- notion:skip -->\n[![CI](badge.svg)](ci)\n<!-- notion:end -->\n" +
+		"<!-- notion:only\nSee the team wiki.\n-->\n" +
+		"<!-- notion:skip profile=public -->\nInternal notes.\n<!-- notion:end -->\n" +
+		"<!-- notion:only profile=internal,ops -->\nOn-call: #ops\n<!-- notion:end -->\n" +
+		"```markdown\n<!-- notion:skip -->\n```\n"
+
+	tests := []struct {
+		name     string
+		profiles []string
+		want     string
+	}{
+		{
+			name: "no profile",
+			want: "# Project\n\n\n\n\nSee the team wiki.\n\n\nInternal notes.\n\n\n\n\n" +
+				"```markdown\n<!-- notion:skip -->\n```\n",
+		},
+		{
+			name:     "public",
+			profiles: []string{"public"},
+			want: "# Project\n\n\n\n\nSee the team wiki.\n\n\n\n\n\n\n\n" +
+				"```markdown\n<!-- notion:skip -->\n```\n",
+		},
+		{
+			name:     "internal",
+			profiles: []string{"internal"},
+			want: "# Project\n\n\n\n\nSee the team wiki.\n\n\nInternal notes.\n\n\nOn-call: #ops\n\n" +
+				"```markdown\n<!-- notion:skip -->\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectMarked([]byte(content), MarkerOptions{Profiles: tt.profiles})
+			if err != nil {
+				t.Fatalf("SelectMarked() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SelectMarked() = %q, want %q", got, tt.want)
+			}
+			if strings.Count(string(got), "\n") != strings.Count(content, "\n") {
+				t.Error("SelectMarked() changed the number of lines")
+			}
+		})
+	}
+}
+
+func TestSelectMarkedNested(t *testing.T) {
+	content := "<!-- notion:skip profile=public -->\nA\n<!-- notion:only profile=internal -->\nB\n<!-- notion:end -->\n<!-- notion:end -->\nC\n"
+	got, err := SelectMarked([]byte(content), MarkerOptions{Profiles: []string{"public", "internal"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "\n\n\n\n\n\nC\n" {
+		t.Errorf("SelectMarked() = %q, want content in a skipped region left out", got)
+	}
+}
+
+func TestSelectMarkedErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"Text\n<!-- notion:skip -->\n", "README.md:2: notion:skip is never closed with notion:end"},
+		{"<!-- notion:only\nText\n", `README.md:1: notion:only comment is never closed with "-->"`},
+		{"<!-- notion:end -->\n", "README.md:1: notion:end without"},
+		{"<!-- notion:hide -->\n", "README.md:1: unknown marker notion:hide"},
+		{"<!-- notion:skip for=public -->\n", `README.md:1: unknown notion:skip attribute "for=public"`},
+	}
+	for _, tt := range tests {
+		_, err := SelectMarked([]byte(tt.content), MarkerOptions{Name: "README.md"})
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("SelectMarked(%q) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
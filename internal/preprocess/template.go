@@ -0,0 +1,290 @@
+// internal/preprocess/template.go
+
+// Package preprocess rewrites Markdown source before it is converted: it
+// composes documents from included files and expands templates so one
+// document can render near-identical pages.
+package preprocess
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateOptions configures the template stage
+type TemplateOptions struct {
+	// Name names the document in errors, usually its path
+	Name string
+	// Dir is the directory Git helpers run in, the working directory when
+	// empty
+	Dir string
+	// Vars are variables from a vars file or the command line. They
+	// override the variables of the document's front matter.
+	Vars map[string]any
+	// Env holds the environment variables, read from the process without
+	// its credentials when nil
+	Env map[string]string
+	// Now is the time date helpers use, time.Now when nil
+	Now func() time.Time
+	// Lines locates errors in the files a document expanded by Include
+	// comes from
+	Lines *LineMap
+}
+
+// TemplateError is a template that failed to parse or execute, located in
+// the source document
+type TemplateError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *TemplateError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// Template expands content as a Go text/template. Variables are available
+// as {{ .name }}, or {{ get "name" }} for variables that may be undefined,
+// environment variables as {{ .Env.NAME }} or {{ env "NAME" }}.
+// The YAML front matter of content provides default variables and is
+// replaced with blank lines, so line numbers still match the source.
+func Template(content []byte, opts TemplateOptions) ([]byte, error) {
+	if opts.Name == "" {
+		opts.Name = "<stdin>"
+	}
+	if opts.Env == nil {
+		opts.Env = environ()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	body, frontMatter, err := SplitFrontMatter(content)
+	if err != nil {
+		return nil, &TemplateError{File: opts.Name, Line: 1, Message: err.Error()}
+	}
+
+	data := make(map[string]any, len(frontMatter)+len(opts.Vars)+1)
+	for name, value := range frontMatter {
+		data[name] = value
+	}
+	for name, value := range opts.Vars {
+		data[name] = value
+	}
+	data["Env"] = opts.Env
+
+	tmpl, err := template.New(opts.Name).
+		Option("missingkey=error").
+		Funcs(templateFuncs(opts, data)).
+		Parse(string(body))
+	if err != nil {
+		return nil, templateError(opts, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, templateError(opts, err)
+	}
+	return out.Bytes(), nil
+}
+
+// SplitFrontMatter separates the YAML front matter delimited by "---" lines
+// at the start of content. The front matter is replaced with as many blank
+// lines in the returned body.
+func SplitFrontMatter(content []byte) ([]byte, map[string]any, error) {
+	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
+		return content, nil, nil
+	}
+
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	for i := 1; i < len(lines); i++ {
+		delimiter := string(bytes.TrimRight(lines[i], "\r\n"))
+		if delimiter != "---" && delimiter != "..." {
+			continue
+		}
+
+		var vars map[string]any
+		if err := yaml.Unmarshal(bytes.Join(lines[1:i], nil), &vars); err != nil {
+			return nil, nil, fmt.Errorf("invalid front matter: %w", err)
+		}
+		rest := bytes.Join(lines[i+1:], nil)
+		body := append(bytes.Repeat([]byte("\n"), i+1), rest...)
+		return body, vars, nil
+	}
+
+	// An unterminated front matter is a thematic break
+	return content, nil, nil
+}
+
+// LoadVars reads variables from a YAML or JSON file
+func LoadVars(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vars map[string]any
+	if err := yaml.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("invalid vars file %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// ParseVar parses a "key=value" variable assignment
+func ParseVar(assignment string) (string, string, error) {
+	name, value, ok := strings.Cut(assignment, "=")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid variable %q, expected key=value", assignment)
+	}
+	return name, value, nil
+}
+
+// secretVariable matches the names of environment variables holding
+// credentials, such as NOTION_TOKEN or AWS_SECRET_ACCESS_KEY
+var secretVariable = regexp.MustCompile(`(?i)TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIAL|PRIVATE_KEY|ACCESS_KEY|API_KEY`)
+
+// environ returns the environment variables of the process, except those
+// holding credentials, which a document could otherwise publish
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if name, value, ok := strings.Cut(entry, "="); ok && !secretVariable.MatchString(name) {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// templateFuncs returns the helper functions available to templates
+// expanded with data
+func templateFuncs(opts TemplateOptions, data map[string]any) template.FuncMap {
+	git := &gitInfo{dir: opts.Dir}
+	return template.FuncMap{
+		"env": func(name string) string {
+			return opts.Env[name]
+		},
+		// get returns a variable, or nil when it is undefined, where
+		// {{ .name }} is an error
+		"get": func(name string) any {
+			return data[name]
+		},
+		"default": func(fallback, value any) any {
+			if value == nil || value == "" {
+				return fallback
+			}
+			return value
+		},
+		"now": opts.Now,
+		// date formats the current time, or the given time, with a Go layout
+		"date": func(layout string, t ...time.Time) string {
+			if len(t) > 0 {
+				return t[0].Format(layout)
+			}
+			return opts.Now().Format(layout)
+		},
+		"gitCommit": func() (string, error) {
+			return git.get("rev-parse", "HEAD")
+		},
+		"gitShortCommit": func() (string, error) {
+			return git.get("rev-parse", "--short", "HEAD")
+		},
+		"gitBranch": func() (string, error) {
+			return git.get("rev-parse", "--abbrev-ref", "HEAD")
+		},
+		"gitTag": func() (string, error) {
+			return git.get("describe", "--tags", "--abbrev=0")
+		},
+		"gitCommitDate": func() (time.Time, error) {
+			value, err := git.get("log", "-1", "--format=%cI")
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Parse(time.RFC3339, value)
+		},
+	}
+}
+
+// gitInfo runs Git commands for template helpers, once per command
+type gitInfo struct {
+	dir string
+
+	mu      sync.Mutex
+	results map[string]gitResult
+}
+
+type gitResult struct {
+	value string
+	err   error
+}
+
+// get returns the trimmed output of a Git command
+func (g *gitInfo) get(args ...string) (string, error) {
+	key := strings.Join(args, " ")
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if result, ok := g.results[key]; ok {
+		return result.value, result.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+
+	var result gitResult
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		result.err = fmt.Errorf("git %s: %s", key, message)
+	} else {
+		result.value = strings.TrimSpace(string(output))
+	}
+
+	if g.results == nil {
+		g.results = make(map[string]gitResult)
+	}
+	g.results[key] = result
+	return result.value, result.err
+}
+
+// templateLocation matches the location text/template puts in its errors
+var templateLocation = regexp.MustCompile(`^template: (.*?):(\d+):(?:(\d+):)? (?:executing ".*?" )?(.*)$`)
+
+// templateError locates a text/template error in the document, or in the
+// file its line was included from
+func templateError(opts TemplateOptions, err error) error {
+	message := err.Error()
+	m := templateLocation.FindStringSubmatch(message)
+	if m == nil || m[1] != opts.Name {
+		return &TemplateError{File: opts.Name, Message: strings.TrimPrefix(message, "template: ")}
+	}
+	line, _ := strconv.Atoi(m[2])
+	column, _ := strconv.Atoi(m[3])
+	file := opts.Name
+	if source, sourceLine, ok := opts.Lines.Locate(line); ok {
+		file, line = source, sourceLine
+	}
+	return &TemplateError{File: file, Line: line, Column: column, Message: m[4]}
+}
@@ -0,0 +1,171 @@
+package preprocess
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplate(t *testing.T) {
+	now := func() time.Time { return time.Date(2024, 3, 9, 10, 0, 0, 0, time.UTC) }
+	opts := TemplateOptions{
+		Name: "release.md",
+		Vars: map[string]any{"version": "1.4.0", "team": "payments"},
+		Env:  map[string]string{"REGION": "eu-west-1"},
+		Now:  now,
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"variables", "# {{ .service }} {{ .version }}\n", "# billing 1.4.0\n"},
+		{"environment", "Region {{ .Env.REGION }}, {{ env \"REGION\" }}\n", "Region eu-west-1, eu-west-1\n"},
+		{"dates", "Released {{ date \"2006-01-02\" }} ({{ now.Year }})\n", "Released 2024-03-09 (2024)\n"},
+		{"default", "Owner {{ .owner | default \"nobody\" }}\n", "Owner nobody\n"},
+		{"default of an undefined variable", "Reviewer {{ get \"reviewer\" | default \"nobody\" }}, team {{ get \"team\" }}\n", "Reviewer nobody, team payments\n"},
+		{
+			"front matter",
+			"---\nservice: billing\nversion: 0.1\nowner: \"\"\n---\n{{ .version }}\n",
+			"\n\n\n\n\n1.4.0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := tt.content
+			if !strings.HasPrefix(content, "---") {
+				content = "---\nservice: billing\nowner: \"\"\n---\n" + content
+			}
+			got, err := Template([]byte(content), opts)
+			if err != nil {
+				t.Fatalf("Template() error = %v", err)
+			}
+			want := tt.want
+			if !strings.HasPrefix(tt.content, "---") {
+				want = "\n\n\n\n" + want
+			}
+			if string(got) != want {
+				t.Errorf("Template() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTemplateEnvironment(t *testing.T) {
+	t.Setenv("MD2NOTION_REGION", "eu-west-1")
+	t.Setenv("NOTION_TOKEN", "secret_token")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret_key")
+
+	got, err := Template([]byte(`{{ env "MD2NOTION_REGION" }} {{ env "NOTION_TOKEN" }}{{ env "AWS_SECRET_ACCESS_KEY" }}`), TemplateOptions{})
+	if err != nil {
+		t.Fatalf("Template() error = %v", err)
+	}
+	if string(got) != "eu-west-1 " {
+		t.Errorf("Template() = %q, want credentials left out", got)
+	}
+	if _, err := Template([]byte(`{{ .Env.NOTION_TOKEN }}`), TemplateOptions{}); err == nil {
+		t.Error("Template() read NOTION_TOKEN from .Env")
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"missing variable", "# Title\n\nVersion {{ .version }}\n", `docs/release.md:3:11: at <.version>: map has no entry for key "version"`},
+		{"parse error", "---\na: b\n---\n\n{{ if }}\n", "docs/release.md:5: missing value for if"},
+		{"unknown function", "{{ nope }}\n", `docs/release.md:1: function "nope" not defined`},
+		{"front matter", "---\n: [\n---\n", "docs/release.md:1: invalid front matter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Template([]byte(tt.content), TemplateOptions{Name: "docs/release.md", Env: map[string]string{}})
+			var templateErr *TemplateError
+			if !errors.As(err, &templateErr) {
+				t.Fatalf("Template() error = %v, want a TemplateError", err)
+			}
+			if !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("Template() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateErrorsInIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.md":  "# Main\n\n<!-- include: part.md -->\n\nVersion {{ .missing }}\n",
+		"main2.md": "# Main\n\n<!-- include: bad.md -->\n",
+		"part.md":  "---\ntitle: Part\n---\n\nPart\n===\n\n{{< include \"data.csv\" >}}\n",
+		"bad.md":   "Bad {{ .missing }}\n",
+		"data.csv": "a,b\n1,2\n",
+	})
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"main.md", "main.md:5:11: "},
+		{"main2.md", "bad.md:1:7: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := &LineMap{}
+			expanded, err := Include(content, IncludeOptions{Path: path, Lines: lines})
+			if err != nil {
+				t.Fatalf("Include() error = %v", err)
+			}
+			_, err = Template(expanded, TemplateOptions{Name: path, Env: map[string]string{}, Lines: lines})
+			if want := filepath.Join(dir, tt.want); err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("Template() error = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	body, vars, err := SplitFrontMatter([]byte("---\ntitle: Notes\n...\nText\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "\n\n\nText\n" || vars["title"] != "Notes" {
+		t.Errorf("SplitFrontMatter() = %q, %v", body, vars)
+	}
+
+	// A lone thematic break is not front matter
+	body, vars, _ = SplitFrontMatter([]byte("---\nText\n"))
+	if string(body) != "---\nText\n" || vars != nil {
+		t.Errorf("SplitFrontMatter() = %q, %v, want the content unchanged", body, vars)
+	}
+}
+
+func TestLoadVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vars.yaml")
+	if err := os.WriteFile(path, []byte("service: billing\nreplicas: 3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	vars, err := LoadVars(path)
+	if err != nil {
+		t.Fatalf("LoadVars() error = %v", err)
+	}
+	if vars["service"] != "billing" || vars["replicas"] != 3 {
+		t.Errorf("LoadVars() = %v", vars)
+	}
+
+	if _, _, err := ParseVar("novalue"); err == nil {
+		t.Error("ParseVar() without = succeeded")
+	}
+	if name, value, _ := ParseVar("url=https://x?a=b"); name != "url" || value != "https://x?a=b" {
+		t.Errorf("ParseVar() = %q, %q", name, value)
+	}
+}
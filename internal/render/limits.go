@@ -0,0 +1,164 @@
+// internal/render/limits.go
+package render
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+)
+
+// Diagnostic kinds reported by the limit transforms
+const (
+	KindHeadingDowngraded = "heading_downgraded"
+	KindCodeSplit         = "code_split"
+	KindTableSplit        = "table_split"
+)
+
+// MaxTableRows is the number of rows Notion accepts in a table block
+const MaxTableRows = 100
+
+// codeSplitSlack is how far before the limit a code block may be split to
+// end a piece at a line break. It keeps lines whole without creating
+// unnecessarily small blocks.
+const codeSplitSlack = 200
+
+// Limits returns the transforms fitting a document within what Notion
+// accepts. With verbose set, splits are logged to stderr.
+func Limits(verbose bool) document.Pipeline {
+	return document.Pipeline{
+		ClampHeadings,
+		SplitCodeBlocks(verbose),
+		SplitTables,
+		SplitLongText,
+	}
+}
+
+// ClampHeadings turns headings below level 3 into level 3 headings, the
+// lowest level Notion has
+func ClampHeadings(doc *document.Document, report document.ReportFunc) error {
+	return document.Walk(doc.Blocks, func(b *document.Block) error {
+		if b.Kind == document.KindHeading && b.Level > 3 {
+			report(b, document.SeverityInfo, KindHeadingDowngraded,
+				fmt.Sprintf("level %d heading converted to heading_3", b.Level))
+			b.Level = 3
+		}
+		return nil
+	})
+}
+
+// SplitCodeBlocks splits code blocks longer than Notion's text limit into
+// consecutive code blocks, preferably at line breaks
+func SplitCodeBlocks(verbose bool) document.Transform {
+	return func(doc *document.Document, report document.ReportFunc) error {
+		blocks, err := document.Rewrite(doc.Blocks, func(b *document.Block) ([]*document.Block, error) {
+			if b.Kind != document.KindCode {
+				return []*document.Block{b}, nil
+			}
+			content := b.Text()
+			chunks := splitText(content, MaxTextLength, codeSplitSlack, true)
+			if len(chunks) == 1 {
+				return []*document.Block{b}, nil
+			}
+
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Splitting code block of %d characters into multiple blocks\n", TextLength(content))
+			}
+			report(b, document.SeverityInfo, KindCodeSplit,
+				fmt.Sprintf("code block split into %d blocks", len(chunks)))
+
+			pieces := make([]*document.Block, 0, len(chunks))
+			for i, chunk := range chunks {
+				piece := *b
+				piece.Spans = []document.Span{document.Text(chunk)}
+				if i > 0 {
+					piece.Anchor = ""
+				}
+				pieces = append(pieces, &piece)
+			}
+			return pieces, nil
+		})
+		if err != nil {
+			return err
+		}
+		doc.Blocks = blocks
+		return nil
+	}
+}
+
+// SplitTables splits tables with more rows than Notion accepts into
+// consecutive tables, repeating the column header row in each of them
+func SplitTables(doc *document.Document, report document.ReportFunc) error {
+	blocks, err := document.Rewrite(doc.Blocks, func(b *document.Block) ([]*document.Block, error) {
+		if b.Kind != document.KindTable || len(b.Children) <= MaxTableRows {
+			return []*document.Block{b}, nil
+		}
+
+		rows := b.Children
+		var header []*document.Block
+		if b.Table != nil && b.Table.HasColumnHeader {
+			header, rows = rows[:1], rows[1:]
+		}
+		size := MaxTableRows - len(header)
+
+		var pieces []*document.Block
+		for start := 0; start < len(rows); start += size {
+			end := min(start+size, len(rows))
+			piece := *b
+			piece.Children = append(append([]*document.Block(nil), header...), rows[start:end]...)
+			if start > 0 {
+				piece.Anchor = ""
+			}
+			pieces = append(pieces, &piece)
+		}
+
+		report(b, document.SeverityInfo, KindTableSplit,
+			fmt.Sprintf("table of %d rows split into %d tables", len(b.Children), len(pieces)))
+		return pieces, nil
+	})
+	if err != nil {
+		return err
+	}
+	doc.Blocks = blocks
+	return nil
+}
+
+// SplitLongText breaks spans longer than Notion's text limit into
+// consecutive spans sharing the same marks and link
+func SplitLongText(doc *document.Document, report document.ReportFunc) error {
+	return document.Walk(doc.Blocks, func(b *document.Block) error {
+		b.EachSpans(func(spans *[]document.Span) {
+			*spans = splitSpans(*spans)
+		})
+		return nil
+	})
+}
+
+// splitSpans splits the spans over the limit, returning spans unchanged
+// when none is
+func splitSpans(spans []document.Span) []document.Span {
+	needsSplit := false
+	for _, span := range spans {
+		if TextLength(span.Text) > MaxTextLength {
+			needsSplit = true
+			break
+		}
+	}
+	if !needsSplit {
+		return spans
+	}
+
+	var result []document.Span
+	for _, span := range spans {
+		if TextLength(span.Text) <= MaxTextLength {
+			result = append(result, span)
+			continue
+		}
+		for _, piece := range splitText(span.Text, MaxTextLength, 0, false) {
+			part := span
+			part.Text = piece
+			result = append(result, part)
+		}
+	}
+	return result
+}
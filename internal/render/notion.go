@@ -0,0 +1,213 @@
+// internal/render/notion.go
+
+// Package render serialises documents to Notion blocks. Notion rejects
+// content over its size limits, so documents should go through the Limits
+// pipeline before being rendered.
+package render
+
+import (
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// Notion converts the blocks of a document to Notion blocks
+func Notion(doc *document.Document) []notion.Block {
+	return renderBlocks(doc.Blocks)
+}
+
+// RichText converts spans to Notion rich text
+func RichText(spans []document.Span) []notion.RichText {
+	return renderSpans(spans)
+}
+
+// renderBlocks converts blocks, returning nil when there are none
+func renderBlocks(blocks []*document.Block) []notion.Block {
+	if len(blocks) == 0 {
+		return nil
+	}
+	result := make([]notion.Block, 0, len(blocks))
+	for _, b := range blocks {
+		if block, ok := renderBlock(b); ok {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+// renderBlock converts a single block; blocks of unknown kinds are left out
+func renderBlock(b *document.Block) (notion.Block, bool) {
+	block := notion.Block{
+		Object: "block",
+		Type:   string(b.Kind),
+		Anchor: b.Anchor,
+		Source: notion.SourceRange{StartLine: b.Source.StartLine, EndLine: b.Source.EndLine},
+	}
+
+	switch b.Kind {
+	case document.KindParagraph:
+		block.Paragraph = &notion.Paragraph{
+			RichText: renderSpans(b.Spans),
+			Children: renderBlocks(b.Children),
+		}
+
+	case document.KindHeading:
+		heading := &notion.Heading{RichText: renderSpans(b.Spans)}
+		switch b.Level {
+		case 1:
+			block.Type = "heading_1"
+			block.Heading1 = heading
+		case 2:
+			block.Type = "heading_2"
+			block.Heading2 = heading
+		default:
+			// Notion has no headings below level 3
+			block.Type = "heading_3"
+			block.Heading3 = heading
+		}
+
+	case document.KindBulletedItem:
+		block.Type = "bulleted_list_item"
+		block.BulletedListItem = &notion.BulletedListItem{
+			RichText: renderSpans(b.Spans),
+			Children: renderBlocks(b.Children),
+		}
+
+	case document.KindNumberedItem:
+		block.Type = "numbered_list_item"
+		block.NumberedListItem = &notion.NumberedListItem{
+			RichText: renderSpans(b.Spans),
+			Children: renderBlocks(b.Children),
+		}
+
+	case document.KindQuote:
+		block.Quote = &notion.Quote{RichText: renderSpans(b.Spans)}
+
+	case document.KindToggle:
+		block.Toggle = &notion.Toggle{
+			RichText: renderSpans(b.Spans),
+			Children: renderBlocks(b.Children),
+		}
+
+	case document.KindCallout:
+		block.Callout = &notion.Callout{
+			RichText: renderSpans(b.Spans),
+			Color:    b.Color,
+			Children: renderBlocks(b.Children),
+		}
+		if b.Icon != "" {
+			block.Callout.Icon = &notion.Icon{Type: "emoji", Emoji: b.Icon}
+		}
+
+	case document.KindToDo:
+		block.ToDo = &notion.ToDo{
+			RichText: renderSpans(b.Spans),
+			Checked:  b.Checked,
+			Children: renderBlocks(b.Children),
+		}
+
+	case document.KindCode:
+		block.Code = &notion.Code{
+			RichText: renderSpans(b.Spans),
+			Language: b.Language,
+			Caption:  []notion.RichText{},
+		}
+
+	case document.KindDivider:
+		block.Divider = &notion.Divider{}
+
+	case document.KindImage:
+		if b.Image == nil {
+			return notion.Block{}, false
+		}
+		block.Image = renderImage(b.Image)
+
+	case document.KindTable:
+		table := &notion.Table{Children: renderBlocks(b.Children)}
+		if b.Table != nil {
+			table.TableWidth = b.Table.Width
+			table.HasColumnHeader = b.Table.HasColumnHeader
+			table.HasRowHeader = b.Table.HasRowHeader
+		}
+		block.Table = table
+
+	case document.KindRaw:
+		raw := notion.RawBlock(b.Raw)
+		raw.Anchor, raw.Source = block.Anchor, block.Source
+		return raw, true
+
+	case document.KindTableRow:
+		var cells [][]notion.RichText
+		for _, cell := range b.Cells {
+			cells = append(cells, renderCell(cell))
+		}
+		block.TableRow = &notion.TableRow{Cells: cells}
+
+	default:
+		return notion.Block{}, false
+	}
+
+	return block, true
+}
+
+// renderImage converts an image to an external image, or to an image
+// waiting for its file to be uploaded
+func renderImage(img *document.Image) *notion.Image {
+	caption := renderSpans(img.Caption)
+	if img.File != nil {
+		return &notion.Image{
+			Type:    "file_upload",
+			Caption: caption,
+			Pending: &notion.FileData{
+				Name:        img.File.Name,
+				ContentType: img.File.ContentType,
+				Data:        img.File.Data,
+			},
+		}
+	}
+	return &notion.Image{
+		Type:     "external",
+		External: &notion.External{URL: img.URL},
+		Caption:  caption,
+	}
+}
+
+// renderCell converts a table cell; Notion needs at least one text element
+// in every cell
+func renderCell(spans []document.Span) []notion.RichText {
+	if len(spans) == 0 {
+		return []notion.RichText{{
+			Type: "text",
+			Text: &notion.Text{Content: ""},
+		}}
+	}
+	return renderSpans(spans)
+}
+
+// renderSpans converts spans to rich text, returning nil when there are none
+func renderSpans(spans []document.Span) []notion.RichText {
+	if len(spans) == 0 {
+		return nil
+	}
+	richText := make([]notion.RichText, 0, len(spans))
+	for _, span := range spans {
+		rt := notion.RichText{
+			Type: "text",
+			Text: &notion.Text{Content: span.Text},
+		}
+		if span.Marks != (document.Marks{}) {
+			rt.Annotations = &notion.Annotations{
+				Bold:          span.Marks.Bold,
+				Italic:        span.Marks.Italic,
+				Strikethrough: span.Marks.Strikethrough,
+				Underline:     span.Marks.Underline,
+				Code:          span.Marks.Code,
+			}
+		}
+		if span.Href != "" {
+			href := span.Href
+			rt.Href = &href
+		}
+		richText = append(richText, rt)
+	}
+	return richText
+}
@@ -0,0 +1,143 @@
+package render
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+)
+
+func TestNotion(t *testing.T) {
+	doc := &document.Document{Blocks: []*document.Block{
+		{Kind: document.KindHeading, Level: 2, Spans: []document.Span{document.Text("Intro")}, Anchor: "intro"},
+		{Kind: document.KindParagraph, Spans: []document.Span{
+			document.Text("plain "),
+			{Text: "bold link", Marks: document.Marks{Bold: true}, Href: "https://example.com"},
+		}},
+		{Kind: document.KindBulletedItem, Spans: []document.Span{document.Text("item")}, Children: []*document.Block{
+			{Kind: document.KindNumberedItem, Spans: []document.Span{document.Text("nested")}},
+		}},
+		{Kind: document.KindImage, Image: &document.Image{File: &document.File{Name: "a.png"}}},
+		{Kind: document.KindTable, Table: &document.Table{Width: 2}, Children: []*document.Block{
+			{Kind: document.KindTableRow, Cells: [][]document.Span{{document.Text("a")}, nil}},
+		}},
+		{Kind: document.KindToggle, Spans: []document.Span{document.Text("term")}, Children: []*document.Block{
+			{Kind: document.KindParagraph, Spans: []document.Span{document.Text("definition")}},
+		}},
+		{Kind: "unknown"},
+	}}
+
+	blocks := Notion(doc)
+	if len(blocks) != 6 {
+		t.Fatalf("got %d blocks, want 6 with the unknown block left out", len(blocks))
+	}
+	if blocks[0].Type != "heading_2" || blocks[0].Anchor != "intro" {
+		t.Errorf("heading = %s anchored %q", blocks[0].Type, blocks[0].Anchor)
+	}
+
+	richText := blocks[1].Paragraph.RichText
+	if richText[0].Annotations != nil || richText[0].Href != nil {
+		t.Errorf("plain text has annotations or a link: %+v", richText[0])
+	}
+	if !richText[1].Annotations.Bold || *richText[1].Href != "https://example.com" {
+		t.Errorf("bold link = %+v", richText[1])
+	}
+
+	if children := blocks[2].BulletedListItem.Children; len(children) != 1 || children[0].Type != "numbered_list_item" {
+		t.Errorf("list item children = %+v", children)
+	}
+	if img := blocks[3].Image; img.Type != "file_upload" || img.Pending == nil || img.Pending.Name != "a.png" {
+		t.Errorf("image = %+v, want a pending upload", img)
+	}
+
+	data, err := json.Marshal(blocks[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"cells":[[{"type":"text","text":{"content":"a"}}],[{"type":"text","text":{"content":""}}]]`) {
+		t.Errorf("table JSON = %s, want the empty cell filled", data)
+	}
+
+	if toggle := blocks[5].Toggle; blocks[5].Type != "toggle" || toggle == nil || len(toggle.Children) != 1 || toggle.Children[0].Paragraph == nil {
+		t.Errorf("toggle = %+v, want the definition as its child", blocks[5])
+	}
+}
+
+func TestLimits(t *testing.T) {
+	code := strings.Repeat(strings.Repeat("x", 99)+"\n", 30)
+	doc := &document.Document{Blocks: []*document.Block{
+		{Kind: document.KindHeading, Level: 5, Spans: []document.Span{document.Text("Deep")}},
+		{Kind: document.KindCode, Language: "go", Spans: []document.Span{document.Text(code)}},
+		{Kind: document.KindQuote, Spans: []document.Span{{Text: strings.Repeat("y", 4500), Marks: document.Marks{Italic: true}}}},
+	}}
+
+	var kinds []string
+	report := func(b *document.Block, severity document.Severity, kind, message string) {
+		kinds = append(kinds, kind)
+	}
+	if err := Limits(false).Apply(doc, report); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if got := strings.Join(kinds, ","); got != KindHeadingDowngraded+","+KindCodeSplit {
+		t.Errorf("reported %s", got)
+	}
+	if doc.Blocks[0].Level != 3 {
+		t.Errorf("heading level = %d, want 3", doc.Blocks[0].Level)
+	}
+	if len(doc.Blocks) != 4 || doc.Blocks[1].Language != "go" || doc.Blocks[2].Language != "go" {
+		t.Fatalf("got %d blocks, want the code split in 2", len(doc.Blocks))
+	}
+	if doc.Blocks[1].Text()+doc.Blocks[2].Text() != code {
+		t.Error("split code does not add up to the original code")
+	}
+
+	spans := doc.Blocks[3].Spans
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want the quote text split in 3", len(spans))
+	}
+	for i, span := range spans {
+		if !span.Marks.Italic || TextLength(span.Text) > MaxTextLength {
+			t.Errorf("span %d is over the limit or lost its marks", i)
+		}
+	}
+}
+
+func TestSplitTables(t *testing.T) {
+	row := func(text string) *document.Block {
+		return &document.Block{Kind: document.KindTableRow, Cells: [][]document.Span{{document.Text(text)}}}
+	}
+	table := &document.Block{Kind: document.KindTable, Anchor: "data", Table: &document.Table{Width: 1, HasColumnHeader: true}}
+	table.Children = append(table.Children, row("header"))
+	for i := 0; i < 250; i++ {
+		table.Children = append(table.Children, row("row"))
+	}
+	doc := &document.Document{Blocks: []*document.Block{table}}
+
+	var messages []string
+	report := func(b *document.Block, severity document.Severity, kind, message string) {
+		messages = append(messages, message)
+	}
+	if err := SplitTables(doc, report); err != nil {
+		t.Fatalf("SplitTables() error = %v", err)
+	}
+
+	if len(messages) != 1 || messages[0] != "table of 251 rows split into 3 tables" {
+		t.Errorf("reported %v", messages)
+	}
+	if len(doc.Blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3 tables", len(doc.Blocks))
+	}
+	for i, piece := range doc.Blocks {
+		if len(piece.Children) > MaxTableRows || piece.Children[0].Cells[0][0].Text != "header" {
+			t.Errorf("table %d has %d rows or no header", i, len(piece.Children))
+		}
+		if (piece.Anchor != "") != (i == 0) {
+			t.Errorf("table %d anchor = %q, want only the first anchored", i, piece.Anchor)
+		}
+	}
+	if rows := len(doc.Blocks[2].Children); rows != 1+250-2*99 {
+		t.Errorf("last table has %d rows", rows)
+	}
+}
@@ -0,0 +1,84 @@
+// internal/render/text.go
+package render
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// MaxTextLength is Notion's limit on the content of a single rich text
+// element. Notion measures it in UTF-16 code units, like JavaScript strings.
+const MaxTextLength = 2000
+
+// TextLength returns the length of s as counted by Notion, in UTF-16 code units
+func TextLength(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// splitText splits s into pieces of at most limit UTF-16 code units without
+// breaking a grapheme cluster. When preferNewline is set, a piece ends after
+// the last line break found within slack units of the limit.
+func splitText(s string, limit, slack int, preferNewline bool) []string {
+	if TextLength(s) <= limit {
+		return []string{s}
+	}
+
+	var pieces []string
+	for s != "" {
+		end, length := 0, 0
+		lastNewline := -1
+		lastNewlineLength := 0
+
+		state := -1
+		rest := s
+		for rest != "" {
+			var cluster string
+			cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+			n := TextLength(cluster)
+			if length+n > limit {
+				break
+			}
+			end += len(cluster)
+			length += n
+			if cluster == "\n" || cluster == "\r\n" {
+				lastNewline, lastNewlineLength = end, length
+			}
+		}
+
+		switch {
+		case end == len(s):
+			// The remainder fits
+		case preferNewline && lastNewline > 0 && lastNewlineLength > limit-slack:
+			end = lastNewline
+		case end == 0:
+			// A single grapheme cluster longer than the limit can only be split between runes
+			end = runePrefix(s, limit)
+		}
+
+		pieces = append(pieces, s[:end])
+		s = s[end:]
+	}
+	return pieces
+}
+
+// runePrefix returns the byte length of the longest prefix of s that fits
+// in limit UTF-16 code units, keeping at least one rune
+func runePrefix(s string, limit int) int {
+	end, length := 0, 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		n := utf16.RuneLen(r)
+		if length+n > limit && end > 0 {
+			break
+		}
+		end += size
+		length += n
+	}
+	return end
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTextLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"日本語", 3},
+		{"😀", 2},
+		{"👍🏽", 4},
+		{"e\u0301", 2},
+	}
+	for _, tt := range tests {
+		if got := TextLength(tt.text); got != tt.want {
+			t.Errorf("TextLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		// unit is the grapheme cluster the text repeats; pieces must be made of whole units
+		unit string
+	}{
+		{"ascii", strings.Repeat("a", 4500), "a"},
+		{"cjk", strings.Repeat("漢", 4500), "漢"},
+		{"emoji", strings.Repeat("😀", 1999), "😀"},
+		{"skin tone emoji", strings.Repeat("👍🏽", 1001), "👍🏽"},
+		{"family emoji", strings.Repeat("👨‍👩‍👧", 300), "👨‍👩‍👧"},
+		{"combining accents", strings.Repeat("e\u0301", 1500), "e\u0301"},
+		{"odd offset", "x" + strings.Repeat("😀", 1500), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pieces := splitText(tt.text, MaxTextLength, 0, false)
+			if len(pieces) < 2 {
+				t.Fatalf("got %d pieces, want the text split", len(pieces))
+			}
+			if joined := strings.Join(pieces, ""); joined != tt.text {
+				t.Fatal("pieces do not add up to the original text")
+			}
+			for i, piece := range pieces {
+				if !utf8.ValidString(piece) {
+					t.Errorf("piece %d is not valid UTF-8", i)
+				}
+				if n := TextLength(piece); n > MaxTextLength || n == 0 {
+					t.Errorf("piece %d has length %d, want 1..%d", i, n, MaxTextLength)
+				}
+				if tt.unit != "" && strings.ReplaceAll(piece, tt.unit, "") != "" {
+					t.Errorf("piece %d splits a grapheme cluster", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitTextPrefersNewlines(t *testing.T) {
+	line := strings.Repeat("漢", 99) + "\n"
+	pieces := splitText(strings.Repeat(line, 30), MaxTextLength, 200, true)
+	for i, piece := range pieces[:len(pieces)-1] {
+		if !strings.HasSuffix(piece, "\n") {
+			t.Errorf("piece %d does not end at a line break", i)
+		}
+	}
+}
+
+func TestSplitTextOversizedCluster(t *testing.T) {
+	// One base character with more combining marks than fit in a piece
+	text := "a" + strings.Repeat("\u0301", 2500)
+	pieces := splitText(text, MaxTextLength, 0, false)
+	if len(pieces) != 2 || strings.Join(pieces, "") != text {
+		t.Fatalf("got %d pieces, want the cluster split in 2", len(pieces))
+	}
+	for i, piece := range pieces {
+		if !utf8.ValidString(piece) || TextLength(piece) > MaxTextLength {
+			t.Errorf("piece %d is invalid or too long", i)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// internal/run/diagnostics.go
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+)
+
+// checkStrict fails when the conversion lost anything, listing the source
+// location of every lossy conversion
+func (r *Runner) checkStrict(diagnostics []convert.Diagnostic) error {
+	var lossy []string
+	for _, d := range diagnostics {
+		if d.Lossy() {
+			lossy = append(lossy, fmt.Sprintf("  %s:%s", r.sourceName(), d))
+		}
+	}
+	if len(lossy) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("strict mode: %d lossy conversions, nothing was published:\n%s",
+		len(lossy), strings.Join(lossy, "\n"))
+}
+
+// sourceName names the Markdown input in messages
+func (r *Runner) sourceName() string {
+	if r.config.MarkdownFile == "" || r.config.MarkdownFile == "-" {
+		return "<stdin>"
+	}
+	return r.config.MarkdownFile
+}
+
+// reportDiagnostics prints a summary of what the conversion dropped or
+// altered and writes the full list as JSON when a diagnostics file is set
+func (r *Runner) reportDiagnostics(diagnostics []convert.Diagnostic) error {
+	if r.config.DiagnosticsFile != "" {
+		if diagnostics == nil {
+			diagnostics = []convert.Diagnostic{}
+		}
+		// Excerpts are Markdown source, keep them readable
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diagnostics); err != nil {
+			return fmt.Errorf("failed to marshal diagnostics: %w", err)
+		}
+		if err := os.WriteFile(r.config.DiagnosticsFile, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write diagnostics file: %w", err)
+		}
+	}
+
+	if len(diagnostics) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	warnings := 0
+	for _, d := range diagnostics {
+		counts[d.Kind]++
+		if d.Severity == convert.SeverityWarning {
+			warnings++
+		}
+	}
+
+	kinds := make([]string, 0, len(counts))
+	for kind, count := range counts {
+		kinds = append(kinds, fmt.Sprintf("%s: %d", kind, count))
+	}
+	sort.Strings(kinds)
+
+	fmt.Fprintf(os.Stderr, "Conversion diagnostics: %d warnings, %d info (%s)\n",
+		warnings, len(diagnostics)-warnings, strings.Join(kinds, ", "))
+
+	// Verbose mode already printed every diagnostic as it was found
+	if !r.config.Verbose {
+		for _, d := range diagnostics {
+			if d.Severity == convert.SeverityWarning {
+				fmt.Fprintf(os.Stderr, "  %s\n", d)
+			}
+		}
+	}
+	return nil
+}
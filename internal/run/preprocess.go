@@ -0,0 +1,83 @@
+// internal/run/preprocess.go
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/preprocess"
+)
+
+// templating reports whether the document is expanded as a template
+func (c *Config) templating() bool {
+	return c.Template || len(c.Vars) > 0 || c.VarsFile != ""
+}
+
+// preprocess rewrites the Markdown source before conversion: it selects
+// the content marked for the profile, expands include directives, then
+// templates
+func (r *Runner) preprocess(content []byte) ([]byte, error) {
+	content, err := preprocess.SelectMarked(content, r.markerOptions())
+	if err != nil {
+		return nil, fmt.Errorf("marker error: %w", err)
+	}
+	includeOpts := r.includeOptions()
+	includeOpts.Lines = &preprocess.LineMap{}
+	content, err = preprocess.Include(content, includeOpts)
+	if err != nil {
+		return nil, fmt.Errorf("include error: %w", err)
+	}
+	if !r.config.templating() {
+		return content, nil
+	}
+
+	vars := make(map[string]any)
+	if r.config.VarsFile != "" {
+		fileVars, err := preprocess.LoadVars(r.config.VarsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load template variables: %w", err)
+		}
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+	for _, assignment := range r.config.Vars {
+		name, value, err := preprocess.ParseVar(assignment)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = value
+	}
+
+	opts := preprocess.TemplateOptions{Name: r.sourceName(), Vars: vars, Lines: includeOpts.Lines}
+	if r.config.MarkdownFile != "" && r.config.MarkdownFile != "-" {
+		opts.Dir = filepath.Dir(r.config.MarkdownFile)
+	}
+	content, err = preprocess.Template(content, opts)
+	if err != nil {
+		return nil, fmt.Errorf("template error: %w", err)
+	}
+	return content, nil
+}
+
+// includeOptions locates the document for include directives
+func (r *Runner) includeOptions() preprocess.IncludeOptions {
+	markers := r.markerOptions()
+	opts := preprocess.IncludeOptions{Markers: &markers}
+	if r.config.MarkdownFile != "" && r.config.MarkdownFile != "-" {
+		opts.Path = r.config.MarkdownFile
+	}
+	return opts
+}
+
+// markerOptions selects the content marked for the configured profiles
+func (r *Runner) markerOptions() preprocess.MarkerOptions {
+	opts := preprocess.MarkerOptions{Name: r.sourceName()}
+	for _, profile := range strings.Split(r.config.Profile, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			opts.Profiles = append(opts.Profiles, profile)
+		}
+	}
+	return opts
+}
@@ -2,15 +2,17 @@
 package run
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/wiremind/markdown-to-notionapi/internal/markdown"
-	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/publish"
 )
 
 // Config holds configuration for the run
@@ -22,19 +24,71 @@ type Config struct {
 	Append        bool
 	Replace       bool
 	ImageBaseURL  string
+	LinkBaseURL   string
+	LinkMapFile   string
 	DryRun        bool
 	OutputFile    string
 	NotionVersion string
 	Verbose       bool
 	Timeout       time.Duration
 	Create        bool
+	LinkFootnotes bool
+	Icon          string
+	NoCaptions    bool
+
+	// DetectLanguage guesses the language of unlabeled code blocks
+	DetectLanguage bool
+	// DefinitionToggles converts definition list terms to toggles
+	DefinitionToggles bool
+	// Dialect is the Markdown flavour of the document: gfm, obsidian or mkdocs
+	Dialect string
+	// TagsProperty is the multi-select property the #tags of an Obsidian
+	// document are set in
+	TagsProperty string
+	// Typography is the locale of typographic punctuation, implied by
+	// TypographySubs, which holds "name=text" substitutions
+	Typography     string
+	TypographySubs []string
+
+	// DiagnosticsFile receives the conversion diagnostics as JSON
+	DiagnosticsFile string
+	// Strict aborts before any API call when the conversion is lossy
+	Strict bool
+	// Stream converts and uploads the document in segments as it is read
+	Stream bool
+
+	// Profile lists the destination profiles selecting marked content,
+	// separated by commas
+	Profile string
+
+	// Template expands the document as a Go template, implied by Vars and
+	// VarsFile. Vars holds "key=value" assignments that override VarsFile.
+	Template bool
+	Vars     []string
+	VarsFile string
+
+	// Image hosting for local images: "notion" (default), "s3", "dir" or "none"
+	ImageUpload string
+	ImageDir    string
+	ImageDirURL string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3Prefix    string
+	S3PublicURL string
+	S3AccessKey string
+	S3SecretKey string
+	// S3SessionToken is set with temporary credentials
+	S3SessionToken string
 }
 
 // Runner orchestrates the conversion and upload process
 type Runner struct {
 	config    *Config
-	client    *notion.Client
-	converter *markdown.Converter
+	client    *notionapi.Client
+	converter *convert.Converter
+	// deferred holds image host uploads back until strict mode checks passed
+	deferred *deferredUploader
 }
 
 // NewRunner creates a new runner instance
@@ -43,19 +97,98 @@ func NewRunner(config *Config, notionToken string) (*Runner, error) {
 		return nil, fmt.Errorf("NOTION_TOKEN environment variable is required")
 	}
 
-	var client *notion.Client
+	var client *notionapi.Client
 	if notionToken != "" {
-		client = notion.NewClient(notionToken, config.NotionVersion, config.Timeout, config.Verbose)
+		client = notionapi.NewClient(notionapi.Options{
+			Token:   notionToken,
+			Version: config.NotionVersion,
+			Timeout: config.Timeout,
+			Verbose: config.Verbose,
+		})
+	}
+
+	uploader, err := newImageUploader(config, config.DryRun)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image upload configuration: %w", err)
+	}
+
+	// Strict mode checks the document before anything is published,
+	// including images sent to an external image host during conversion
+	var deferred *deferredUploader
+	if config.Strict && uploader != nil && !config.DryRun {
+		preview, err := newImageUploader(config, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image upload configuration: %w", err)
+		}
+		deferred = &deferredUploader{preview: preview, uploader: uploader}
+		uploader = deferred
+	}
+
+	converter, err := newConverter(config, uploader)
+	if err != nil {
+		return nil, err
 	}
-	converter := markdown.NewConverter(config.ImageBaseURL, config.Verbose)
 
 	return &Runner{
 		config:    config,
 		client:    client,
 		converter: converter,
+		deferred:  deferred,
 	}, nil
 }
 
+// newConverter creates the Markdown converter for the configuration, which
+// sends local images to uploader
+func newConverter(config *Config, uploader convert.ImageUploader) (*convert.Converter, error) {
+	opts := convert.Options{
+		ImageBaseURL:      config.ImageBaseURL,
+		LinkBaseURL:       config.LinkBaseURL,
+		LinkFootnotes:     config.LinkFootnotes,
+		NoImageCaptions:   config.NoCaptions,
+		DetectLanguage:    config.DetectLanguage,
+		DefinitionToggles: config.DefinitionToggles,
+		Verbose:           config.Verbose,
+	}
+	if config.MarkdownFile != "" && config.MarkdownFile != "-" {
+		opts.SourceFile = config.MarkdownFile
+	}
+	opts.ImageUploader = uploader
+	dialect, err := convert.ParseDialect(config.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	opts.Dialect = dialect
+	if config.Typography != "" || len(config.TypographySubs) > 0 {
+		typography, err := newTypography(config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid typography: %w", err)
+		}
+		opts.Typography = typography
+	}
+	if config.LinkMapFile != "" {
+		links, err := convert.LoadLinkMap(config.LinkMapFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load link map: %w", err)
+		}
+		opts.LinkMap = links
+	}
+
+	return convert.New(opts), nil
+}
+
+// newTypography builds the typography of the configured locale and substitutions
+func newTypography(config *Config) (convert.Typography, error) {
+	overrides := make(map[string]string, len(config.TypographySubs))
+	for _, sub := range config.TypographySubs {
+		name, text, ok := strings.Cut(sub, "=")
+		if !ok {
+			return nil, fmt.Errorf("substitution %q must be name=text", sub)
+		}
+		overrides[strings.TrimSpace(name)] = text
+	}
+	return convert.TypographyFor(config.Typography, overrides)
+}
+
 // Run executes the conversion and upload process
 func (r *Runner) Run(ctx context.Context) error {
 	// Validate configuration
@@ -63,17 +196,44 @@ func (r *Runner) Run(ctx context.Context) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	if r.config.Stream {
+		return r.runStream(ctx)
+	}
+
 	// Read markdown content
 	content, err := r.readMarkdownContent()
 	if err != nil {
 		return fmt.Errorf("failed to read markdown content: %w", err)
 	}
 
+	content, err = r.preprocess(content)
+	if err != nil {
+		return err
+	}
+
 	// Convert markdown to Notion blocks
-	blocks, err := r.converter.Convert(content)
+	page, diagnostics, err := r.converter.ConvertPageContext(ctx, content)
 	if err != nil {
 		return fmt.Errorf("failed to convert markdown: %w", err)
 	}
+	blocks := page.Blocks
+
+	// The report is written before strict mode fails, as it lists what
+	// made the conversion lossy
+	if err := r.reportDiagnostics(diagnostics); err != nil {
+		return err
+	}
+
+	if r.config.Strict {
+		if err := r.checkStrict(diagnostics); err != nil {
+			return err
+		}
+	}
+	if r.deferred != nil {
+		if err := r.deferred.flush(ctx); err != nil {
+			return fmt.Errorf("failed to upload images: %w", err)
+		}
+	}
 
 	if len(blocks) == 0 {
 		fmt.Fprintf(os.Stderr, "No content to upload\n")
@@ -82,6 +242,9 @@ func (r *Runner) Run(ctx context.Context) error {
 
 	if r.config.Verbose {
 		fmt.Fprintf(os.Stderr, "Converted %d blocks\n", len(blocks))
+		if len(page.Tags) > 0 {
+			fmt.Fprintf(os.Stderr, "Page tags: %s\n", strings.Join(page.Tags, ", "))
+		}
 	}
 
 	// Handle dry run
@@ -89,18 +252,64 @@ func (r *Runner) Run(ctx context.Context) error {
 		return r.printDryRun(blocks)
 	}
 
-	// Execute the appropriate operation
-	if r.config.Create {
-		return r.createPage(ctx, blocks)
-	} else if r.config.Replace {
-		return r.replacePage(ctx, blocks)
-	} else {
-		return r.appendToPage(ctx, blocks)
+	opts := r.publishOptions()
+	opts.Tags = page.Tags
+	result, err := publish.Blocks(ctx, r.client, blocks, opts)
+	if err != nil {
+		return err
 	}
+
+	r.printResult(result)
+	return nil
+}
+
+// printResult prints the URL of the published page
+func (r *Runner) printResult(result *publish.Result) {
+	switch {
+	case r.config.Create:
+		fmt.Printf("Created page: %s\n", result.URL)
+	case r.config.Replace:
+		fmt.Printf("Replaced content of page: %s\n", result.URL)
+	default:
+		fmt.Printf("Updated page: %s\n", result.URL)
+	}
+}
+
+// publishOptions describes the target page for the publish package
+func (r *Runner) publishOptions() publish.Options {
+	opts := publish.Options{
+		Mode:         publish.ModeAppend,
+		PageID:       r.config.PageID,
+		ParentID:     r.config.ParentID,
+		Title:        r.config.Title,
+		Icon:         r.config.Icon,
+		TagsProperty: r.config.TagsProperty,
+		Verbose:      r.config.Verbose,
+	}
+	switch {
+	case r.config.Create:
+		opts.Mode = publish.ModeCreate
+	case r.config.Replace:
+		opts.Mode = publish.ModeReplace
+	}
+	return opts
 }
 
 // validateConfig validates the runner configuration
 func (r *Runner) validateConfig() error {
+	// Strict mode converts the whole document before publishing it
+	if r.config.Stream && r.config.Strict {
+		return fmt.Errorf("--strict and --stream cannot be used together")
+	}
+	// Tags are collected from the whole document
+	if r.config.Stream && r.config.TagsProperty != "" {
+		return fmt.Errorf("--tags-property and --stream cannot be used together")
+	}
+
+	if _, err := convert.EmojiIcon(r.config.Icon); err != nil {
+		return err
+	}
+
 	// Skip page/parent ID validation for dry-run mode
 	if r.config.DryRun {
 		return nil
@@ -138,96 +347,53 @@ func (r *Runner) readMarkdownContent() ([]byte, error) {
 }
 
 // printDryRun prints the blocks that would be uploaded
-func (r *Runner) printDryRun(blocks []notion.Block) error {
-	req := notion.AppendBlockChildrenRequest{Children: blocks}
-	data, err := json.MarshalIndent(req, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal blocks for dry run: %w", err)
-	}
-
-	// Write to file if specified, otherwise to stdout
-	if r.config.OutputFile != "" {
-		if err := os.WriteFile(r.config.OutputFile, data, 0600); err != nil {
-			return fmt.Errorf("failed to write to output file: %w", err)
-		}
-		if r.config.Verbose {
-			fmt.Fprintf(os.Stderr, "Dry-run output written to: %s\n", r.config.OutputFile)
+func (r *Runner) printDryRun(blocks []notionapi.Block) error {
+	return r.writeDryRun(func(enc *notionapi.ChildrenEncoder) error {
+		for _, block := range blocks {
+			if err := enc.Encode(block); err != nil {
+				return err
+			}
 		}
-	} else {
-		fmt.Print(string(data))
-	}
-	return nil
+		return nil
+	})
 }
 
-// createPage creates a new page with the converted blocks
-func (r *Runner) createPage(ctx context.Context, blocks []notion.Block) error {
-	if r.config.Verbose {
-		fmt.Fprintf(os.Stderr, "Creating new page '%s' under parent %s\n", r.config.Title, r.config.ParentID)
+// writeDryRun writes the request body encoded by encode to the output file,
+// or to stdout when none is set
+func (r *Runner) writeDryRun(encode func(*notionapi.ChildrenEncoder) error) error {
+	if r.config.OutputFile == "" {
+		return encodeDryRun(os.Stdout, encode)
 	}
 
-	page, err := r.client.CreatePage(ctx, r.config.ParentID, r.config.Title, blocks)
+	file, err := os.OpenFile(r.config.OutputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		return fmt.Errorf("failed to create page: %w", err)
+		return fmt.Errorf("failed to write to output file: %w", err)
 	}
-
-	fmt.Printf("Created page: %s\n", page.URL)
-	return nil
-}
-
-// replacePage replaces the content of an existing page
-func (r *Runner) replacePage(ctx context.Context, blocks []notion.Block) error {
-	if r.config.Verbose {
-		fmt.Fprintf(os.Stderr, "Replacing content of page %s\n", r.config.PageID)
+	if err := encodeDryRun(file, encode); err != nil {
+		_ = file.Close()
+		return err
 	}
-
-	// Get existing children
-	existingBlocks, err := r.client.ListBlockChildren(ctx, r.config.PageID)
-	if err != nil {
-		return fmt.Errorf("failed to list existing blocks: %w", err)
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write to output file: %w", err)
 	}
-
-	// Delete all existing blocks first (simple sequential deletion)
-	if len(existingBlocks) > 0 {
-		if r.config.Verbose {
-			fmt.Fprintf(os.Stderr, "Deleting %d existing blocks...\n", len(existingBlocks))
-		}
-		for i, block := range existingBlocks {
-			if r.config.Verbose && i%10 == 0 {
-				fmt.Fprintf(os.Stderr, "Deleted %d/%d blocks\n", i, len(existingBlocks))
-			}
-			if err := r.client.DeleteBlock(ctx, block.ID); err != nil {
-				if r.config.Verbose {
-					fmt.Fprintf(os.Stderr, "Warning: failed to delete block %s: %v\n", block.ID, err)
-				}
-			}
-		}
-		if r.config.Verbose {
-			fmt.Fprintf(os.Stderr, "Finished deleting all %d existing blocks\n", len(existingBlocks))
-		}
-	}
-
-	// Now add new blocks
 	if r.config.Verbose {
-		fmt.Fprintf(os.Stderr, "Adding %d new blocks...\n", len(blocks))
-	}
-	if err := r.client.AppendBlockChildren(ctx, r.config.PageID, blocks); err != nil {
-		return fmt.Errorf("failed to append new blocks: %w", err)
+		fmt.Fprintf(os.Stderr, "Dry-run output written to: %s\n", r.config.OutputFile)
 	}
-
-	fmt.Printf("Replaced content of page: https://notion.so/%s\n", r.config.PageID)
 	return nil
 }
 
-// appendToPage appends blocks to an existing page
-func (r *Runner) appendToPage(ctx context.Context, blocks []notion.Block) error {
-	if r.config.Verbose {
-		fmt.Fprintf(os.Stderr, "Appending %d blocks to page %s\n", len(blocks), r.config.PageID)
+// encodeDryRun writes the request body encoded by encode to out
+func encodeDryRun(out io.Writer, encode func(*notionapi.ChildrenEncoder) error) error {
+	w := bufio.NewWriter(out)
+	enc := notionapi.NewChildrenEncoder(w)
+	if err := encode(enc); err != nil {
+		return fmt.Errorf("failed to write dry run output: %w", err)
 	}
-
-	if err := r.client.AppendBlockChildren(ctx, r.config.PageID, blocks); err != nil {
-		return fmt.Errorf("failed to append blocks: %w", err)
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to write dry run output: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write dry run output: %w", err)
 	}
-
-	fmt.Printf("Updated page: https://notion.so/%s\n", r.config.PageID)
 	return nil
 }
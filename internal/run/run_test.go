@@ -0,0 +1,52 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStrictWritesDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	markdown := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(markdown, []byte("# Title\n\nText with <b>HTML</b>.\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{
+		MarkdownFile:    markdown,
+		DryRun:          true,
+		OutputFile:      filepath.Join(dir, "out.json"),
+		Strict:          true,
+		DiagnosticsFile: filepath.Join(dir, "diagnostics.json"),
+	}
+	runner, err := NewRunner(config, "")
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+
+	err = runner.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "strict mode") {
+		t.Fatalf("Run() error = %v, want a strict mode error", err)
+	}
+
+	data, err := os.ReadFile(config.DiagnosticsFile)
+	if err != nil {
+		t.Fatalf("diagnostics file not written: %v", err)
+	}
+	var diagnostics []struct {
+		Kind string `json:"kind"`
+		Line int    `json:"line"`
+	}
+	if err := json.Unmarshal(data, &diagnostics); err != nil {
+		t.Fatalf("invalid diagnostics file: %v", err)
+	}
+	if len(diagnostics) == 0 || diagnostics[0].Kind != "html_dropped" || diagnostics[0].Line != 3 {
+		t.Errorf("diagnostics = %+v, want the dropped HTML", diagnostics)
+	}
+	if _, err := os.Stat(config.OutputFile); !os.IsNotExist(err) {
+		t.Errorf("dry-run output written despite the strict mode error: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// internal/run/stream.go
+package run
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/preprocess"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/publish"
+)
+
+// runStream converts the document as it is read and uploads or prints the
+// blocks as they are converted, without holding the whole document
+func (r *Runner) runStream(ctx context.Context) error {
+	input, err := r.openMarkdown()
+	if err != nil {
+		return fmt.Errorf("failed to read markdown content: %w", err)
+	}
+	defer input.Close()
+
+	// Templates are expanded on the whole document, markers and includes as
+	// it is read
+	var markdown io.Reader
+	if r.config.templating() {
+		content, err := io.ReadAll(input)
+		if err != nil {
+			return fmt.Errorf("failed to read markdown content: %w", err)
+		}
+		content, err = r.preprocess(content)
+		if err != nil {
+			return err
+		}
+		markdown = bytes.NewReader(content)
+	} else {
+		markdown = preprocess.SelectMarkedReader(input, r.markerOptions())
+		markdown = preprocess.IncludeReader(markdown, r.includeOptions())
+	}
+
+	if r.config.DryRun {
+		return r.streamDryRun(ctx, markdown)
+	}
+
+	result, diagnostics, err := publish.MarkdownStream(ctx, r.client, r.converter, markdown, r.publishOptions())
+	if reportErr := r.reportDiagnostics(diagnostics); reportErr != nil && err == nil {
+		err = reportErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.config.Verbose {
+		fmt.Fprintf(os.Stderr, "Uploaded %d blocks\n", result.Blocks)
+	}
+	r.printResult(result)
+	return nil
+}
+
+// streamDryRun prints the blocks that would be uploaded as they are converted
+func (r *Runner) streamDryRun(ctx context.Context, input io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blocks := make(chan notionapi.Block)
+	var diagnostics []convert.Diagnostic
+	var convertErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		diagnostics, convertErr = r.converter.ConvertStream(ctx, input, blocks)
+	}()
+
+	count := 0
+	err := r.writeDryRun(func(enc *notionapi.ChildrenEncoder) error {
+		for block := range blocks {
+			if err := enc.Encode(block); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		// Stop the conversion and let it close the channel
+		cancel()
+		for range blocks {
+		}
+	}
+	<-done
+
+	if convertErr != nil && err == nil {
+		err = fmt.Errorf("failed to convert markdown: %w", convertErr)
+	}
+	if reportErr := r.reportDiagnostics(diagnostics); reportErr != nil && err == nil {
+		err = reportErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.config.Verbose {
+		fmt.Fprintf(os.Stderr, "Converted %d blocks\n", count)
+	}
+	return nil
+}
+
+// openMarkdown opens the markdown file, or stdin when none is set
+func (r *Runner) openMarkdown() (io.ReadCloser, error) {
+	if r.config.MarkdownFile == "" || r.config.MarkdownFile == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(r.config.MarkdownFile)
+}
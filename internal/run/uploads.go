@@ -0,0 +1,67 @@
+// internal/run/uploads.go
+package run
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+)
+
+// newImageUploader creates the image host selected by the configuration.
+// It returns nil when local images are uploaded to Notion itself.
+func newImageUploader(config *Config, dryRun bool) (convert.ImageUploader, error) {
+	switch config.ImageUpload {
+	case "", "notion":
+		return nil, nil
+	case "none":
+		return convert.NoopUploader, nil
+	case "dir":
+		return convert.NewDirectoryUploader(config.ImageDir, config.ImageDirURL, dryRun)
+	case "s3":
+		return convert.NewS3Uploader(convert.S3Config{
+			Endpoint:     config.S3Endpoint,
+			Region:       config.S3Region,
+			Bucket:       config.S3Bucket,
+			Prefix:       config.S3Prefix,
+			AccessKey:    config.S3AccessKey,
+			SecretKey:    config.S3SecretKey,
+			SessionToken: config.S3SessionToken,
+			PublicURL:    config.S3PublicURL,
+			Timeout:      config.Timeout,
+			DryRun:       dryRun,
+		})
+	default:
+		return nil, fmt.Errorf("unknown image upload backend %q (want notion, s3, dir or none)", config.ImageUpload)
+	}
+}
+
+// deferredUploader hands out the URLs images will have on the image host
+// without uploading them, until flush uploads them all. Strict mode checks
+// the conversion in between, so a lossy document uploads nothing.
+type deferredUploader struct {
+	preview  convert.ImageUploader
+	uploader convert.ImageUploader
+	files    []notionapi.FileData
+}
+
+func (d *deferredUploader) Upload(ctx context.Context, file notionapi.FileData) (string, error) {
+	url, err := d.preview.Upload(ctx, file)
+	if err != nil || url == "" {
+		return url, err
+	}
+	d.files = append(d.files, file)
+	return url, nil
+}
+
+// flush uploads the images handed out since the last flush
+func (d *deferredUploader) flush(ctx context.Context) error {
+	for _, file := range d.files {
+		if _, err := d.uploader.Upload(ctx, file); err != nil {
+			return err
+		}
+	}
+	d.files = nil
+	return nil
+}
@@ -0,0 +1,258 @@
+// md2notion/convert/convert.go
+
+// Package convert turns Markdown into Notion blocks.
+//
+// Conversion runs locally and never calls the Notion API; local images are
+// either attached to the blocks for upload by the publish package or sent to
+// the ImageUploader set in Options.
+package convert
+
+import (
+	"context"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/markdown"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/document"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Converter converts Markdown documents to Notion blocks. A Converter keeps
+// no state between documents and is safe for concurrent use, provided the
+// extensions, handlers, transforms and image uploader it is given are.
+//
+// Handlers are passed the Converter of the document being converted, whose
+// ConvertChildren, ConvertInline, ResolveLink and Report methods convert
+// nested content and record diagnostics.
+type Converter struct {
+	conv *markdown.Converter
+}
+
+// Source is a Markdown document converted by Converter.ConvertBatch.
+// When Content is nil the document is read from Path.
+type Source struct {
+	// Path locates the document. Relative links and images are resolved
+	// against its directory.
+	Path    string
+	Content []byte
+}
+
+// BatchResult is the outcome of converting one Source
+type BatchResult struct {
+	Path        string
+	Blocks      []notionapi.Block
+	Diagnostics []Diagnostic
+	Err         error
+}
+
+// Diagnostic describes content the converter dropped or altered
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	Message  string   `json:"message"`
+	// Line and Column are 1-based; zero when the position is unknown
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// Lossy reports whether the diagnostic means the Notion page will not hold
+// exactly what the Markdown says
+func (d Diagnostic) Lossy() bool {
+	return markdown.Diagnostic(d).Lossy()
+}
+
+// String formats the diagnostic as "line:col: severity: message"
+func (d Diagnostic) String() string {
+	return markdown.Diagnostic(d).String()
+}
+
+// Severity tells how much a diagnostic matters
+type Severity = document.Severity
+
+// Diagnostic severities
+const (
+	SeverityInfo    = document.SeverityInfo
+	SeverityWarning = document.SeverityWarning
+)
+
+// Diagnostic kinds
+const (
+	KindHTMLDropped         = markdown.KindHTMLDropped
+	KindUnsupportedNode     = markdown.KindUnsupportedNode
+	KindImageSkipped        = markdown.KindImageSkipped
+	KindHeadingDowngraded   = markdown.KindHeadingDowngraded
+	KindCodeSplit           = markdown.KindCodeSplit
+	KindUnknownLanguage     = markdown.KindUnknownLanguage
+	KindLanguageDetected    = markdown.KindLanguageDetected
+	KindTableSplit          = markdown.KindTableSplit
+	KindInvalidTableData    = markdown.KindInvalidTableData
+	KindInvalidRawBlock     = markdown.KindInvalidRawBlock
+	KindUnresolvedReference = markdown.KindUnresolvedReference
+)
+
+// ImageUploader hosts local and embedded images. Upload returns the public
+// URL of an image, or an empty URL to leave it out of the page.
+type ImageUploader interface {
+	Upload(ctx context.Context, file notionapi.FileData) (string, error)
+}
+
+// BlockHandler converts a block node to Notion blocks. The Converter passed
+// in is the one of the current document: its ConvertChildren, ConvertInline
+// and Report methods convert nested content and record diagnostics.
+type BlockHandler func(c *Converter, node ast.Node, source []byte) ([]notionapi.Block, error)
+
+// InlineHandler converts an inline node to rich text
+type InlineHandler func(c *Converter, node ast.Node, source []byte) ([]notionapi.RichText, error)
+
+// Page is a converted document with its page metadata, returned by
+// Converter.ConvertPage
+type Page struct {
+	Blocks []notionapi.Block
+	// Tags are the page tags found in the document, such as Obsidian #tags
+	Tags []string
+}
+
+// Dialect is a flavour of Markdown with syntax beyond CommonMark
+type Dialect string
+
+// Markdown dialects
+const (
+	DialectGFM      Dialect = Dialect(markdown.DialectGFM)
+	DialectObsidian Dialect = Dialect(markdown.DialectObsidian)
+	DialectMkDocs   Dialect = Dialect(markdown.DialectMkDocs)
+)
+
+// Typography maps punctuation names to the typographic punctuation written
+// for them
+type Typography map[string]string
+
+// LinkMap maps Markdown files to the Notion pages or URLs they are published at
+type LinkMap map[string]string
+
+// Options configures a Converter. The zero value converts with the defaults.
+type Options struct {
+	// ImageBaseURL is joined with relative image paths
+	ImageBaseURL string
+	// LinkBaseURL is joined with relative links that are not in LinkMap
+	LinkBaseURL string
+	// LinkMap rewrites relative links to mapped documents to their Notion pages
+	LinkMap LinkMap
+	// SourceFile is the path of the document, used to resolve relative paths.
+	// Without it they are resolved against the working directory.
+	SourceFile string
+	// ImageUploader hosts local images instead of uploading them to Notion
+	ImageUploader ImageUploader
+	// LinkFootnotes makes footnote references link to their notes
+	LinkFootnotes bool
+	// NoImageCaptions disables captions built from image titles and alt text
+	NoImageCaptions bool
+	// Dialect parses the syntax of a Markdown flavour: GFM, Obsidian or
+	// MkDocs. The zero value is plain Markdown.
+	Dialect Dialect
+	// Typography turns straight quotes, dashes and ellipses into typographic
+	// punctuation, outside code. Build it with TypographyFor.
+	Typography Typography
+	// DefinitionToggles converts the terms of definition lists to toggles
+	// holding their definitions, instead of bold paragraphs
+	DefinitionToggles bool
+	// DetectLanguage guesses the language of code blocks that have none
+	// instead of tagging them as plain text
+	DetectLanguage bool
+	// Extensions are added to the Markdown parser, e.g. to parse custom syntax
+	Extensions []goldmark.Extender
+	// BlockHandlers and InlineHandlers convert nodes of the given kinds,
+	// replacing the built-in conversions. A nil handler disables the
+	// conversion of a kind.
+	BlockHandlers  map[ast.NodeKind]BlockHandler
+	InlineHandlers map[ast.NodeKind]InlineHandler
+	// Transforms rewrite every document before it is rendered for Notion
+	Transforms []document.Transform
+	// Verbose logs conversion details to stderr
+	Verbose bool
+}
+
+// New creates a Converter
+func New(opts Options) *Converter {
+	options := []markdown.Option{
+		markdown.WithFootnoteLinks(opts.LinkFootnotes),
+		markdown.WithLinkBaseURL(opts.LinkBaseURL),
+		markdown.WithImageCaptions(!opts.NoImageCaptions),
+		markdown.WithLanguageDetection(opts.DetectLanguage),
+		markdown.WithDefinitionToggles(opts.DefinitionToggles),
+	}
+	if opts.SourceFile != "" {
+		options = append(options, markdown.WithSourceFile(opts.SourceFile))
+	}
+	if opts.LinkMap != nil {
+		options = append(options, markdown.WithLinkMap(markdown.LinkMap(opts.LinkMap)))
+	}
+	if opts.ImageUploader != nil {
+		options = append(options, markdown.WithImageUploader(opts.ImageUploader))
+	}
+	if opts.Dialect != "" {
+		options = append(options, markdown.WithDialect(markdown.Dialect(opts.Dialect)))
+	}
+	if opts.Typography != nil {
+		options = append(options, markdown.WithTypography(markdown.Typography(opts.Typography)))
+	}
+	if len(opts.Extensions) > 0 {
+		options = append(options, markdown.WithExtensions(opts.Extensions...))
+	}
+	if len(opts.Transforms) > 0 {
+		options = append(options, markdown.WithTransforms(opts.Transforms...))
+	}
+	for kind, h := range opts.BlockHandlers {
+		options = append(options, markdown.WithBlockHandler(kind, blockHandler(h)))
+	}
+	for kind, h := range opts.InlineHandlers {
+		options = append(options, markdown.WithInlineHandler(kind, inlineHandler(h)))
+	}
+
+	return &Converter{conv: markdown.NewConverter(opts.ImageBaseURL, opts.Verbose, options...)}
+}
+
+// Convert converts a Markdown document with a Converter created from opts
+func Convert(markdown []byte, opts Options) ([]notionapi.Block, []Diagnostic, error) {
+	return New(opts).Convert(markdown)
+}
+
+// NewLinkMap builds a LinkMap from entries whose keys are paths relative to
+// baseDir and whose values are Notion page IDs or URLs
+func NewLinkMap(baseDir string, entries map[string]string) (LinkMap, error) {
+	links, err := markdown.NewLinkMap(baseDir, entries)
+	return LinkMap(links), err
+}
+
+// LoadLinkMap reads a JSON link map; paths are relative to the map file
+func LoadLinkMap(path string) (LinkMap, error) {
+	links, err := markdown.LoadLinkMap(path)
+	return LinkMap(links), err
+}
+
+// ParseDialect returns the dialect of a name: gfm, obsidian, mkdocs, or ""
+// for plain Markdown
+func ParseDialect(name string) (Dialect, error) {
+	dialect, err := markdown.ParseDialect(name)
+	return Dialect(dialect), err
+}
+
+// TypographyFor returns the typography of a locale, "en" for curly quotes or
+// "fr" for guillemets, with overrides keyed by punctuation name such as
+// left_double_quote or em_dash
+func TypographyFor(locale string, overrides map[string]string) (Typography, error) {
+	typography, err := markdown.TypographyFor(locale, overrides)
+	return Typography(typography), err
+}
+
+// EmojiIcon builds a page icon from a shortcode such as ":rocket:" or a
+// literal emoji. An empty value yields no icon, and any other value is an
+// error.
+func EmojiIcon(value string) (*notionapi.Icon, error) {
+	return markdown.EmojiIcon(value)
+}
+
+// Slugify turns heading text into a GitHub-style anchor
+func Slugify(text string) string {
+	return document.Slugify(text)
+}
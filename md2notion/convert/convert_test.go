@@ -0,0 +1,192 @@
+package convert_test
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/document"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+	"github.com/yuin/goldmark/ast"
+)
+
+// The exported API must keep these signatures; changing one is a breaking
+// change. Every assertion is a declaration of its own so that adding one never
+// touches the others: append new assertions and leave existing ones as they are.
+var _ func(convert.Options) *convert.Converter = convert.New
+var _ func([]byte, convert.Options) ([]notionapi.Block, []convert.Diagnostic, error) = convert.Convert
+var _ func(*convert.Converter, []byte) ([]notionapi.Block, []convert.Diagnostic, error) = (*convert.Converter).Convert
+var _ func(string, map[string]string) (convert.LinkMap, error) = convert.NewLinkMap
+var _ func(string) (convert.LinkMap, error) = convert.LoadLinkMap
+var _ func(string) (*notionapi.Icon, error) = convert.EmojiIcon
+var _ func(string, map[string]string) (convert.Typography, error) = convert.TypographyFor
+var _ func(string) (convert.Dialect, error) = convert.ParseDialect
+var _ func(*convert.Converter, []byte) (*convert.Page, []convert.Diagnostic, error) = (*convert.Converter).ConvertPage
+var _ func(convert.S3Config) (convert.ImageUploader, error) = convert.NewS3Uploader
+var _ func(string, string, bool) (convert.ImageUploader, error) = convert.NewDirectoryUploader
+var _ func(*convert.Converter, []byte) (*document.Document, []convert.Diagnostic, error) = (*convert.Converter).Parse
+var _ func(*convert.Converter, context.Context, []convert.Source, int) []convert.BatchResult = (*convert.Converter).ConvertBatch
+var _ func(*convert.Converter, context.Context, io.Reader, chan<- notionapi.Block) ([]convert.Diagnostic, error) = (*convert.Converter).ConvertStream
+var _ func(*convert.Converter, ast.Node, []byte) ([]notionapi.Block, error) = (*convert.Converter).ConvertChildren
+var _ func(*convert.Converter, ast.Node, []byte) ([]notionapi.RichText, error) = (*convert.Converter).ConvertInline
+var _ func(*convert.Converter, ast.Node, []byte, convert.Severity, string, string) = (*convert.Converter).Report
+var _ func(convert.Diagnostic) bool = convert.Diagnostic.Lossy
+var _ interface {
+	Upload(context.Context, notionapi.FileData) (string, error)
+} = convert.NoopUploader
+var _ func(*convert.Converter, context.Context, []byte) ([]notionapi.Block, []convert.Diagnostic, error) = (*convert.Converter).ConvertContext
+var _ func(*convert.Converter, context.Context, []byte) (*convert.Page, []convert.Diagnostic, error) = (*convert.Converter).ConvertPageContext
+var _ func(*convert.Converter, string) string = (*convert.Converter).ResolveLink
+var _ func(convert.Diagnostic) string = convert.Diagnostic.String
+
+// TestConverterMethods keeps the method set of Converter to the public API,
+// so methods of the converter behind it never leak into it
+func TestConverterMethods(t *testing.T) {
+	want := []string{
+		"Convert", "ConvertBatch", "ConvertChildren", "ConvertContext", "ConvertInline",
+		"ConvertPage", "ConvertPageContext", "ConvertStream", "Parse", "Report", "ResolveLink",
+	}
+	typ := reflect.TypeOf(&convert.Converter{})
+	var got []string
+	for i := 0; i < typ.NumMethod(); i++ {
+		got = append(got, typ.Method(i).Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Converter methods = %v, want %v", got, want)
+	}
+}
+
+func TestConvertZeroOptions(t *testing.T) {
+	blocks, diagnostics, err := convert.Convert([]byte("# Report\n\nAll **green**.\n"), convert.Options{})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("got diagnostics %v, want none", diagnostics)
+	}
+	if len(blocks) != 2 || blocks[0].Type != "heading_1" || blocks[1].Type != "paragraph" {
+		t.Fatalf("got %d blocks, want a heading and a paragraph", len(blocks))
+	}
+}
+
+func TestConvertOptions(t *testing.T) {
+	converter := convert.New(convert.Options{
+		LinkBaseURL:     "https://example.com/docs/",
+		NoImageCaptions: true,
+	})
+
+	blocks, _, err := converter.Convert([]byte("[guide](guide.md)\n\n![alt](https://example.com/a.png)\n"))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+
+	link := blocks[0].Paragraph.RichText[0].Href
+	if link == nil || *link != "https://example.com/docs/guide.md" {
+		t.Errorf("link = %v, want it joined with the link base URL", link)
+	}
+	if caption := blocks[1].Image.Caption; len(caption) != 0 {
+		t.Errorf("image caption = %v, want none", caption)
+	}
+}
+
+func TestEmojiIcon(t *testing.T) {
+	icon, err := convert.EmojiIcon(":rocket:")
+	if err != nil || icon == nil || icon.Emoji != "🚀" {
+		t.Errorf("EmojiIcon(:rocket:) = %+v, %v; want the rocket emoji", icon, err)
+	}
+	if _, err := convert.EmojiIcon("rocket ship"); err == nil {
+		t.Error("EmojiIcon(rocket ship) succeeded")
+	}
+}
+
+func TestConvertCustomHandlers(t *testing.T) {
+	// Render inline code as upper-case bold text
+	shout := func(c *convert.Converter, node ast.Node, source []byte) ([]notionapi.RichText, error) {
+		return []notionapi.RichText{{
+			Type:        "text",
+			Text:        &notionapi.Text{Content: strings.ToUpper(string(node.Text(source)))},
+			Annotations: &notionapi.Annotations{Bold: true},
+		}}, nil
+	}
+	blocks, _, err := convert.Convert([]byte("Run `make test`.\n"), convert.Options{
+		InlineHandlers: map[ast.NodeKind]convert.InlineHandler{ast.KindCodeSpan: shout},
+	})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	code := blocks[0].Paragraph.RichText[1]
+	if code.Text.Content != "MAKE TEST" || !code.Annotations.Bold || code.Annotations.Code {
+		t.Errorf("code span = %q %+v, want the custom conversion", code.Text.Content, code.Annotations)
+	}
+}
+
+func TestConvertCustomBlockHandler(t *testing.T) {
+	// Render quotes as callouts holding their content
+	callout := func(c *convert.Converter, node ast.Node, source []byte) ([]notionapi.Block, error) {
+		children, err := c.ConvertChildren(node, source)
+		if err != nil {
+			return nil, err
+		}
+		return []notionapi.Block{{
+			Object:  "block",
+			Type:    "callout",
+			Callout: &notionapi.Callout{RichText: []notionapi.RichText{}, Children: children},
+		}}, nil
+	}
+	markdown := "> ## Heads up\n>\n> ![dot](data:image/gif;base64,R0lGODlhAQABAAAAACw=)\n"
+	blocks, _, err := convert.Convert([]byte(markdown), convert.Options{
+		BlockHandlers: map[ast.NodeKind]convert.BlockHandler{ast.KindBlockquote: callout},
+	})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if len(blocks) != 1 || blocks[0].Callout == nil || len(blocks[0].Callout.Children) != 2 {
+		t.Fatalf("blocks = %+v, want a callout holding a heading and an image", blocks)
+	}
+	heading, image := blocks[0].Callout.Children[0], blocks[0].Callout.Children[1]
+	// Blocks of handlers go through the document pipeline
+	if heading.Heading2 == nil || heading.Anchor != "heads-up" {
+		t.Errorf("heading = %+v, want a heading_2 with an anchor", heading)
+	}
+	if image.Image == nil || image.Image.Pending == nil || len(image.Image.Pending.Data) == 0 {
+		t.Errorf("image = %+v, want the embedded image waiting for its upload", image)
+	}
+}
+
+func TestConvertTransforms(t *testing.T) {
+	// Number the top-level headings
+	number := func(doc *document.Document, report document.ReportFunc) error {
+		n := 0
+		for _, b := range doc.Blocks {
+			if b.Kind == document.KindHeading && b.Level == 1 {
+				n++
+				b.Spans = append([]document.Span{document.Text(strconv.Itoa(n) + ". ")}, b.Spans...)
+			}
+		}
+		return nil
+	}
+	blocks, _, err := convert.Convert([]byte("# Intro\n\ntext\n\n# Usage\n"), convert.Options{
+		Transforms: []document.Transform{number},
+	})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if got := blocks[2].Heading1.RichText[0].Text.Content; got != "2. " {
+		t.Errorf("second heading starts with %q, want it numbered", got)
+	}
+	// Anchors are assigned before transforms run
+	if blocks[2].Anchor != "usage" {
+		t.Errorf("anchor = %q, want usage", blocks[2].Anchor)
+	}
+}
@@ -0,0 +1,129 @@
+// md2notion/convert/converter.go
+package convert
+
+import (
+	"context"
+	"io"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/markdown"
+	"github.com/wiremind/markdown-to-notionapi/internal/render"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/document"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Convert parses Markdown content and returns Notion blocks, along with
+// diagnostics for the content that was dropped or altered on the way
+func (c *Converter) Convert(markdown []byte) ([]notionapi.Block, []Diagnostic, error) {
+	return c.ConvertContext(context.Background(), markdown)
+}
+
+// ConvertContext converts Markdown content like Convert. Images sent to the
+// image uploader are uploaded with ctx.
+func (c *Converter) ConvertContext(ctx context.Context, markdown []byte) ([]notionapi.Block, []Diagnostic, error) {
+	blocks, diagnostics, err := c.conv.ConvertContext(ctx, markdown)
+	return blocks, publicDiagnostics(diagnostics), err
+}
+
+// ConvertPage converts Markdown content like Convert, along with the page
+// metadata it holds
+func (c *Converter) ConvertPage(markdown []byte) (*Page, []Diagnostic, error) {
+	return c.ConvertPageContext(context.Background(), markdown)
+}
+
+// ConvertPageContext converts Markdown content like ConvertPage, uploading
+// images with ctx
+func (c *Converter) ConvertPageContext(ctx context.Context, markdown []byte) (*Page, []Diagnostic, error) {
+	page, diagnostics, err := c.conv.ConvertPageContext(ctx, markdown)
+	if page == nil {
+		return nil, publicDiagnostics(diagnostics), err
+	}
+	return &Page{Blocks: page.Blocks, Tags: page.Tags}, publicDiagnostics(diagnostics), err
+}
+
+// Parse parses Markdown content into a document, without the transforms
+// Convert applies before rendering it for Notion
+func (c *Converter) Parse(markdown []byte) (*document.Document, []Diagnostic, error) {
+	doc, diagnostics, err := c.conv.Parse(markdown)
+	return doc, publicDiagnostics(diagnostics), err
+}
+
+// ConvertBatch converts sources with a pool of workers and returns their
+// results in the order of sources. With workers <= 0 it uses one worker per
+// CPU. Once ctx is done the sources not yet converted fail with its error.
+func (c *Converter) ConvertBatch(ctx context.Context, sources []Source, workers int) []BatchResult {
+	batch := make([]markdown.Source, len(sources))
+	for i, src := range sources {
+		batch[i] = markdown.Source(src)
+	}
+
+	results := c.conv.ConvertBatch(ctx, batch, workers)
+	converted := make([]BatchResult, len(results))
+	for i, r := range results {
+		converted[i] = BatchResult{
+			Path:        r.Path,
+			Blocks:      r.Blocks,
+			Diagnostics: publicDiagnostics(r.Diagnostics),
+			Err:         r.Err,
+		}
+	}
+	return converted
+}
+
+// ConvertStream converts Markdown read from r and sends the top-level blocks
+// to out as they are converted, closing out when done. The document is read
+// and converted in segments cut at blank lines between top-level blocks, so
+// memory use is bounded by the segment size and the largest block rather
+// than by the document size.
+//
+// Headings, footnote numbers and source lines are counted across segments,
+// and link reference definitions resolve the references of later segments.
+// Footnotes only resolve within their segment, and references used before
+// the segment defining them are left as text; both are reported with a
+// KindUnresolvedReference diagnostic. Diagnostics are returned in source
+// order once the whole document is converted.
+func (c *Converter) ConvertStream(ctx context.Context, r io.Reader, out chan<- notionapi.Block) ([]Diagnostic, error) {
+	diagnostics, err := c.conv.ConvertStream(ctx, r, out)
+	return publicDiagnostics(diagnostics), err
+}
+
+// ConvertChildren converts the block children of a node, for handlers of
+// container nodes
+func (c *Converter) ConvertChildren(node ast.Node, source []byte) ([]notionapi.Block, error) {
+	blocks, err := c.conv.ConvertChildren(node, source)
+	if err != nil {
+		return nil, err
+	}
+	return renderBlocks(blocks), nil
+}
+
+// ConvertInline converts the inline children of a node to rich text
+func (c *Converter) ConvertInline(node ast.Node, source []byte) ([]notionapi.RichText, error) {
+	spans, err := c.conv.ConvertInline(node, source)
+	if err != nil {
+		return nil, err
+	}
+	return render.RichText(spans), nil
+}
+
+// ResolveLink rewrites a link destination the way Markdown links are rewritten
+func (c *Converter) ResolveLink(href string) string {
+	return c.conv.ResolveLink(href)
+}
+
+// Report records a diagnostic for content a handler dropped or altered
+func (c *Converter) Report(node ast.Node, source []byte, severity Severity, kind, message string) {
+	c.conv.Report(node, source, severity, kind, message)
+}
+
+// publicDiagnostics converts the diagnostics of the converter
+func publicDiagnostics(diagnostics []markdown.Diagnostic) []Diagnostic {
+	if diagnostics == nil {
+		return nil
+	}
+	converted := make([]Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		converted[i] = Diagnostic(d)
+	}
+	return converted
+}
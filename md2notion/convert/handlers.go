@@ -0,0 +1,231 @@
+// md2notion/convert/handlers.go
+package convert
+
+import (
+	"encoding/json"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/markdown"
+	"github.com/wiremind/markdown-to-notionapi/internal/render"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/document"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+	"github.com/yuin/goldmark/ast"
+)
+
+// blockHandler adapts a handler to the converter of the current document.
+// The blocks of the handler are mapped back to document blocks, so heading
+// anchors, transforms and the limits of Notion apply to them.
+func blockHandler(h BlockHandler) markdown.BlockHandler {
+	if h == nil {
+		return nil
+	}
+	return func(c *markdown.Converter, node ast.Node, source []byte) ([]*document.Block, error) {
+		blocks, err := h(&Converter{conv: c}, node, source)
+		if err != nil {
+			return nil, err
+		}
+		return documentBlocks(blocks)
+	}
+}
+
+// inlineHandler adapts a handler to the converter of the current document
+func inlineHandler(h InlineHandler) markdown.InlineHandler {
+	if h == nil {
+		return nil
+	}
+	return func(c *markdown.Converter, node ast.Node, source []byte) ([]document.Span, error) {
+		richText, err := h(&Converter{conv: c}, node, source)
+		if err != nil {
+			return nil, err
+		}
+		spans, exact := documentSpans(richText)
+		if !exact {
+			c.Report(node, source, markdown.SeverityWarning, markdown.KindUnsupportedNode,
+				"rich text colors and non-text elements dropped")
+		}
+		return spans, nil
+	}
+}
+
+// renderBlocks renders document blocks converted for a handler
+func renderBlocks(blocks []*document.Block) []notionapi.Block {
+	return render.Notion(&document.Document{Blocks: blocks})
+}
+
+// documentBlocks maps Notion blocks to document blocks. Blocks the model
+// cannot represent exactly, such as colored text, are passed through as raw
+// blocks.
+func documentBlocks(blocks []notionapi.Block) ([]*document.Block, error) {
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	result := make([]*document.Block, 0, len(blocks))
+	for _, b := range blocks {
+		block, err := documentBlock(b)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, block)
+	}
+	return result, nil
+}
+
+// documentBlock maps a single Notion block to a document block
+func documentBlock(b notionapi.Block) (*document.Block, error) {
+	block, ok := mapBlock(b)
+	if !ok {
+		if b.Raw != nil {
+			block = &document.Block{Kind: document.KindRaw, Raw: b.Raw}
+		} else {
+			data, err := json.Marshal(b)
+			if err != nil {
+				return nil, err
+			}
+			block = &document.Block{Kind: document.KindRaw, Raw: data}
+		}
+	}
+	block.Anchor = b.Anchor
+	block.Source = document.SourceRange{StartLine: b.Source.StartLine, EndLine: b.Source.EndLine}
+	return block, nil
+}
+
+// mapBlock maps a Notion block to the document block it renders from
+func mapBlock(b notionapi.Block) (*document.Block, bool) {
+	block := &document.Block{}
+	var richText []notionapi.RichText
+	var children []notionapi.Block
+	switch {
+	case b.Raw != nil:
+		return nil, false
+	case b.Paragraph != nil && b.Paragraph.Color == "":
+		block.Kind = document.KindParagraph
+		richText, children = b.Paragraph.RichText, b.Paragraph.Children
+	case b.Heading1 != nil && b.Heading1.Color == "":
+		block.Kind, block.Level = document.KindHeading, 1
+		richText = b.Heading1.RichText
+	case b.Heading2 != nil && b.Heading2.Color == "":
+		block.Kind, block.Level = document.KindHeading, 2
+		richText = b.Heading2.RichText
+	case b.Heading3 != nil && b.Heading3.Color == "":
+		block.Kind, block.Level = document.KindHeading, 3
+		richText = b.Heading3.RichText
+	case b.BulletedListItem != nil && b.BulletedListItem.Color == "":
+		block.Kind = document.KindBulletedItem
+		richText, children = b.BulletedListItem.RichText, b.BulletedListItem.Children
+	case b.NumberedListItem != nil && b.NumberedListItem.Color == "":
+		block.Kind = document.KindNumberedItem
+		richText, children = b.NumberedListItem.RichText, b.NumberedListItem.Children
+	case b.Quote != nil && b.Quote.Color == "":
+		block.Kind = document.KindQuote
+		richText = b.Quote.RichText
+	case b.Toggle != nil:
+		block.Kind = document.KindToggle
+		richText, children = b.Toggle.RichText, b.Toggle.Children
+	case b.Callout != nil && (b.Callout.Icon == nil || b.Callout.Icon.Type == "emoji"):
+		block.Kind, block.Color = document.KindCallout, b.Callout.Color
+		if b.Callout.Icon != nil {
+			block.Icon = b.Callout.Icon.Emoji
+		}
+		richText, children = b.Callout.RichText, b.Callout.Children
+	case b.ToDo != nil:
+		block.Kind, block.Checked = document.KindToDo, b.ToDo.Checked
+		richText, children = b.ToDo.RichText, b.ToDo.Children
+	case b.Code != nil && len(b.Code.Caption) == 0:
+		block.Kind, block.Language = document.KindCode, b.Code.Language
+		richText = b.Code.RichText
+	case b.Divider != nil:
+		block.Kind = document.KindDivider
+	case b.Image != nil && b.Image.FileUpload == nil && (b.Image.External != nil || b.Image.Pending != nil):
+		image, ok := documentImage(b.Image)
+		if !ok {
+			return nil, false
+		}
+		block.Kind, block.Image = document.KindImage, image
+	case b.Table != nil:
+		block.Kind = document.KindTable
+		block.Table = &document.Table{
+			Width:           b.Table.TableWidth,
+			HasColumnHeader: b.Table.HasColumnHeader,
+			HasRowHeader:    b.Table.HasRowHeader,
+		}
+		children = b.Table.Children
+	case b.TableRow != nil:
+		block.Kind = document.KindTableRow
+		for _, cell := range b.TableRow.Cells {
+			spans, ok := documentSpans(cell)
+			if !ok {
+				return nil, false
+			}
+			block.Cells = append(block.Cells, spans)
+		}
+	default:
+		return nil, false
+	}
+
+	spans, ok := documentSpans(richText)
+	if !ok {
+		return nil, false
+	}
+	block.Spans = spans
+	for _, child := range children {
+		childBlock, ok := mapBlock(child)
+		if !ok {
+			return nil, false
+		}
+		childBlock.Anchor = child.Anchor
+		childBlock.Source = document.SourceRange{StartLine: child.Source.StartLine, EndLine: child.Source.EndLine}
+		block.Children = append(block.Children, childBlock)
+	}
+	return block, true
+}
+
+// documentImage maps an external image or an image waiting for its upload
+func documentImage(img *notionapi.Image) (*document.Image, bool) {
+	caption, ok := documentSpans(img.Caption)
+	if !ok {
+		return nil, false
+	}
+	image := &document.Image{Caption: caption}
+	if img.Pending != nil {
+		image.File = &document.File{
+			Name:        img.Pending.Name,
+			ContentType: img.Pending.ContentType,
+			Data:        img.Pending.Data,
+		}
+	} else {
+		image.URL = img.External.URL
+	}
+	return image, true
+}
+
+// documentSpans maps rich text to spans. Spans have no colors and only hold
+// text, so colors and other elements are dropped, and exact is false.
+func documentSpans(richText []notionapi.RichText) (spans []document.Span, exact bool) {
+	exact = true
+	for _, rt := range richText {
+		if rt.Type != "text" || rt.Text == nil {
+			exact = false
+			continue
+		}
+		span := document.Span{Text: rt.Text.Content}
+		if a := rt.Annotations; a != nil {
+			if a.Color != "" && a.Color != "default" {
+				exact = false
+			}
+			span.Marks = document.Marks{
+				Bold:          a.Bold,
+				Italic:        a.Italic,
+				Strikethrough: a.Strikethrough,
+				Underline:     a.Underline,
+				Code:          a.Code,
+			}
+		}
+		switch {
+		case rt.Href != nil:
+			span.Href = *rt.Href
+		case rt.Text.Link != nil:
+			span.Href = rt.Text.Link.URL
+		}
+		spans = append(spans, span)
+	}
+	return spans, exact
+}
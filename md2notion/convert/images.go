@@ -0,0 +1,49 @@
+// md2notion/convert/images.go
+package convert
+
+import (
+	"time"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/imagestore"
+)
+
+// S3Config configures an S3-compatible image host
+type S3Config struct {
+	// Endpoint is the base URL of the object store, e.g. https://s3.eu-west-1.amazonaws.com
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// SessionToken is set with temporary credentials, such as those of an
+	// assumed role
+	SessionToken string
+	// PublicURL is the base URL objects are served from; defaults to the bucket URL
+	PublicURL string
+	Timeout   time.Duration
+	// DryRun computes the URLs of images without uploading them
+	DryRun bool
+}
+
+// NewS3Uploader creates an ImageUploader storing images in an S3-compatible bucket
+func NewS3Uploader(config S3Config) (ImageUploader, error) {
+	store, err := imagestore.NewS3(imagestore.S3Config(config))
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// NewDirectoryUploader creates an ImageUploader copying images into dir,
+// which is published at baseURL. With dryRun set, nothing is written.
+func NewDirectoryUploader(dir, baseURL string, dryRun bool) (ImageUploader, error) {
+	store, err := imagestore.NewDirectory(dir, baseURL, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// NoopUploader is an ImageUploader that leaves local images out
+var NoopUploader ImageUploader = imagestore.Noop{}
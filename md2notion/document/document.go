@@ -0,0 +1,90 @@
+// md2notion/document/document.go
+
+// Package document is the intermediate model Markdown is converted to
+// before it is rendered as Notion blocks: a tree of blocks holding spans of
+// text with marks.
+//
+// Transforms rewrite documents between the two steps. They are set with
+// convert.Options.Transforms, and Converter.Parse returns the document of
+// a Markdown file as parsed.
+package document
+
+import (
+	"github.com/wiremind/markdown-to-notionapi/internal/document"
+)
+
+// Model types
+type (
+	Document    = document.Document
+	Block       = document.Block
+	Kind        = document.Kind
+	Image       = document.Image
+	File        = document.File
+	Table       = document.Table
+	Span        = document.Span
+	Marks       = document.Marks
+	SourceRange = document.SourceRange
+)
+
+// Block kinds
+const (
+	KindParagraph    = document.KindParagraph
+	KindHeading      = document.KindHeading
+	KindBulletedItem = document.KindBulletedItem
+	KindNumberedItem = document.KindNumberedItem
+	KindQuote        = document.KindQuote
+	KindToggle       = document.KindToggle
+	KindCallout      = document.KindCallout
+	KindToDo         = document.KindToDo
+	KindCode         = document.KindCode
+	KindDivider      = document.KindDivider
+	KindImage        = document.KindImage
+	KindTable        = document.KindTable
+	KindTableRow     = document.KindTableRow
+	KindRaw          = document.KindRaw
+)
+
+// Severity tells how much a diagnostic reported by a transform matters
+type Severity = document.Severity
+
+// Diagnostic severities
+const (
+	SeverityInfo    = document.SeverityInfo
+	SeverityWarning = document.SeverityWarning
+)
+
+// ReportFunc records a diagnostic about a block a transform altered or dropped
+type ReportFunc = document.ReportFunc
+
+// Transform rewrites a document in place
+type Transform = document.Transform
+
+// Pipeline is a sequence of transforms applied in order
+type Pipeline = document.Pipeline
+
+// Text returns a span with plain text
+func Text(text string) Span {
+	return document.Text(text)
+}
+
+// SpansText returns the plain text of spans
+func SpansText(spans []Span) string {
+	return document.SpansText(spans)
+}
+
+// Walk calls fn for every block of the tree in document order, parents
+// before their children, and stops at the first error
+func Walk(blocks []*Block, fn func(b *Block) error) error {
+	return document.Walk(blocks, fn)
+}
+
+// Rewrite replaces every block of the tree with the blocks fn returns for
+// it, children first
+func Rewrite(blocks []*Block, fn func(b *Block) ([]*Block, error)) ([]*Block, error) {
+	return document.Rewrite(blocks, fn)
+}
+
+// Slugify turns heading text into a GitHub-style anchor
+func Slugify(text string) string {
+	return document.Slugify(text)
+}
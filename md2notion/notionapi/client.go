@@ -0,0 +1,68 @@
+// md2notion/notionapi/client.go
+package notionapi
+
+import (
+	"context"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// Client calls the Notion API. It is safe for concurrent use.
+type Client struct {
+	client *notion.Client
+}
+
+// AppendBlockChildren appends blocks to a page or block. Blocks are split
+// into chunks to respect the limit of blocks per request. The created
+// top-level blocks are returned in order, carrying their new IDs.
+func (c *Client) AppendBlockChildren(ctx context.Context, blockID string, blocks []Block) ([]Block, error) {
+	return c.client.AppendBlockChildren(ctx, blockID, blocks)
+}
+
+// AppendBlockStream appends the blocks received from blocks to a page or
+// block as they arrive, in chunks like AppendBlockChildren, until the channel
+// is closed. After each chunk is created, onChunk is called with the chunk
+// and the created blocks. It returns the number of blocks appended. On
+// error it stops reading blocks; producers should stop when ctx is cancelled.
+func (c *Client) AppendBlockStream(ctx context.Context, blockID string, blocks <-chan Block, onChunk func(chunk, created []Block) error) (int, error) {
+	return c.client.AppendBlockStream(ctx, blockID, blocks, onChunk)
+}
+
+// UpdateBlock replaces the content of an existing block with the content of
+// block. Only the type payload is sent; nested children are left untouched.
+func (c *Client) UpdateBlock(ctx context.Context, block Block) error {
+	return c.client.UpdateBlock(ctx, block)
+}
+
+// ListBlockChildren retrieves all child blocks of a block
+func (c *Client) ListBlockChildren(ctx context.Context, blockID string) ([]Block, error) {
+	return c.client.ListBlockChildren(ctx, blockID)
+}
+
+// DeleteBlock archives a block
+func (c *Client) DeleteBlock(ctx context.Context, blockID string) error {
+	return c.client.DeleteBlock(ctx, blockID)
+}
+
+// CreatePage creates a new page under a parent page, then appends blocks to
+// it in chunks. icon may be nil.
+func (c *Client) CreatePage(ctx context.Context, parentID, title string, icon *Icon, blocks []Block) (*PageResponse, error) {
+	return c.client.CreatePage(ctx, parentID, title, icon, blocks)
+}
+
+// SetPageIcon replaces the icon of an existing page
+func (c *Client) SetPageIcon(ctx context.Context, pageID string, icon *Icon) error {
+	return c.client.SetPageIcon(ctx, pageID, icon)
+}
+
+// SetPageTags sets the multi-select property of a database page to tags
+func (c *Client) SetPageTags(ctx context.Context, pageID, property string, tags []string) error {
+	return c.client.SetPageTags(ctx, pageID, property, tags)
+}
+
+// UploadFile uploads a file to Notion and returns the file upload ID to
+// reference from blocks. Files with identical content are uploaded once per
+// client.
+func (c *Client) UploadFile(ctx context.Context, file FileData) (string, error) {
+	return c.client.UploadFile(ctx, file)
+}
@@ -0,0 +1,122 @@
+// md2notion/notionapi/notionapi.go
+
+// Package notionapi is a client for the parts of the Notion API used to
+// publish Markdown: pages, block children and file uploads.
+package notionapi
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+const (
+	// DefaultVersion is the Notion API version used when none is set
+	DefaultVersion = "2022-06-28"
+	// DefaultTimeout is the HTTP request timeout used when none is set
+	DefaultTimeout = 1000 * time.Second
+	// BlockChunkSize is the number of blocks sent per append request
+	BlockChunkSize = notion.BlockChunkSize
+	// MaxSinglePartUploadSize is the largest file UploadFile accepts
+	MaxSinglePartUploadSize = notion.MaxSinglePartUploadSize
+)
+
+// Notion objects sent to and received from the API. They are plain data
+// shared with the convert package and encode to the JSON of the API.
+type (
+	Block            = notion.Block
+	RichText         = notion.RichText
+	Text             = notion.Text
+	Link             = notion.Link
+	Annotations      = notion.Annotations
+	Paragraph        = notion.Paragraph
+	Heading          = notion.Heading
+	Code             = notion.Code
+	Quote            = notion.Quote
+	Divider          = notion.Divider
+	Image            = notion.Image
+	External         = notion.External
+	FileUploadRef    = notion.FileUploadRef
+	FileData         = notion.FileData
+	BulletedListItem = notion.BulletedListItem
+	NumberedListItem = notion.NumberedListItem
+	Toggle           = notion.Toggle
+	Callout          = notion.Callout
+	ToDo             = notion.ToDo
+	PropertyValue    = notion.PropertyValue
+	SelectOption     = notion.SelectOption
+	Table            = notion.Table
+	TableRow         = notion.TableRow
+	Icon             = notion.Icon
+	PageResponse     = notion.PageResponse
+	PageProperties   = notion.PageProperties
+	SourceRange      = notion.SourceRange
+
+	AppendBlockChildrenRequest = notion.AppendBlockChildrenRequest
+)
+
+// ChildrenEncoder writes blocks as an append block children request body
+// one block at a time, producing the same JSON as an indented
+// AppendBlockChildrenRequest
+type ChildrenEncoder struct {
+	enc *notion.ChildrenEncoder
+}
+
+// NewChildrenEncoder returns a ChildrenEncoder writing to w
+func NewChildrenEncoder(w io.Writer) *ChildrenEncoder {
+	return &ChildrenEncoder{enc: notion.NewChildrenEncoder(w)}
+}
+
+// Encode writes the next block of the request
+func (e *ChildrenEncoder) Encode(block Block) error {
+	return e.enc.Encode(block)
+}
+
+// Close ends the request. It does not close the underlying writer.
+func (e *ChildrenEncoder) Close() error {
+	return e.enc.Close()
+}
+
+// ParseRawBlocks parses hand-written Notion JSON, a block object or an
+// array of them, into blocks sent to Notion verbatim
+func ParseRawBlocks(data []byte) ([]Block, error) {
+	return notion.ParseRawBlocks(data)
+}
+
+// APIError is an error response returned by the Notion API
+type APIError = notion.APIError
+
+// FailedBlock is a block rejected by the Notion API
+type FailedBlock = notion.FailedBlock
+
+// Options configures a Client
+type Options struct {
+	// Token is the Notion integration token
+	Token string
+	// Version is the Notion-Version header, DefaultVersion when empty
+	Version string
+	// Timeout bounds each HTTP request, DefaultTimeout when zero
+	Timeout time.Duration
+	// HTTPClient sends the requests instead of a client built from Timeout
+	HTTPClient *http.Client
+	// Verbose logs requests and responses to stderr
+	Verbose bool
+}
+
+// NewClient creates a Notion API client
+func NewClient(opts Options) *Client {
+	if opts.Version == "" {
+		opts.Version = DefaultVersion
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	client := notion.NewClient(opts.Token, opts.Version, opts.Timeout, opts.Verbose)
+	if opts.HTTPClient != nil {
+		client.SetHTTPClient(opts.HTTPClient)
+	}
+	return &Client{client: client}
+}
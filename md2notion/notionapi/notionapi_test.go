@@ -0,0 +1,80 @@
+package notionapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+)
+
+// The exported API must keep these signatures; changing one is a breaking
+// change. Every assertion is a declaration of its own so that adding one never
+// touches the others: append new assertions and leave existing ones as they are.
+var _ func(notionapi.Options) *notionapi.Client = notionapi.NewClient
+var _ func(*notionapi.Client, context.Context, string, []notionapi.Block) ([]notionapi.Block, error) = (*notionapi.Client).AppendBlockChildren
+var _ func(*notionapi.Client, context.Context, string) ([]notionapi.Block, error) = (*notionapi.Client).ListBlockChildren
+var _ func(*notionapi.Client, context.Context, string) error = (*notionapi.Client).DeleteBlock
+var _ func(*notionapi.Client, context.Context, notionapi.Block) error = (*notionapi.Client).UpdateBlock
+var _ func(*notionapi.Client, context.Context, string, string, *notionapi.Icon, []notionapi.Block) (*notionapi.PageResponse, error) = (*notionapi.Client).CreatePage
+var _ func(*notionapi.Client, context.Context, string, *notionapi.Icon) error = (*notionapi.Client).SetPageIcon
+var _ func(*notionapi.Client, context.Context, notionapi.FileData) (string, error) = (*notionapi.Client).UploadFile
+var _ func(*notionapi.Client, context.Context, string, <-chan notionapi.Block, func(chunk, created []notionapi.Block) error) (int, error) = (*notionapi.Client).AppendBlockStream
+var _ func(io.Writer) *notionapi.ChildrenEncoder = notionapi.NewChildrenEncoder
+var _ error = (*notionapi.APIError)(nil)
+var _ func(*notionapi.Client, context.Context, string, string, []string) error = (*notionapi.Client).SetPageTags
+var _ func(*notionapi.ChildrenEncoder, notionapi.Block) error = (*notionapi.ChildrenEncoder).Encode
+var _ func(*notionapi.ChildrenEncoder) error = (*notionapi.ChildrenEncoder).Close
+
+// TestClientMethods keeps the method set of Client to the public API, so
+// methods of the client behind it never leak into it
+func TestClientMethods(t *testing.T) {
+	want := []string{
+		"AppendBlockChildren", "AppendBlockStream", "CreatePage", "DeleteBlock",
+		"ListBlockChildren", "SetPageIcon", "SetPageTags", "UpdateBlock", "UploadFile",
+	}
+	typ := reflect.TypeOf(&notionapi.Client{})
+	var got []string
+	for i := 0; i < typ.NumMethod(); i++ {
+		got = append(got, typ.Method(i).Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Client methods = %v, want %v", got, want)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewClientDefaults(t *testing.T) {
+	var version, auth string
+	client := notionapi.NewClient(notionapi.Options{
+		Token: "secret",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			version = req.Header.Get("Notion-Version")
+			auth = req.Header.Get("Authorization")
+			return &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"object":"list","results":[]}`)),
+			}, nil
+		})},
+	})
+
+	if _, err := client.ListBlockChildren(context.Background(), "page"); err != nil {
+		t.Fatalf("ListBlockChildren() error = %v", err)
+	}
+	if version != notionapi.DefaultVersion {
+		t.Errorf("Notion-Version = %q, want %q", version, notionapi.DefaultVersion)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the bearer token", auth)
+	}
+}
@@ -0,0 +1,249 @@
+// md2notion/publish/links.go
+package publish
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// linker points intra-page "#anchor" links, such as links to headings or
+// footnotes, at the uploaded blocks that carry the matching anchor. Blocks
+// are added as they are uploaded; links to anchors uploaded later are
+// resolved by finish.
+type linker struct {
+	p      *publisher
+	pageID string
+	// known holds the anchors links may point at, or is nil when any
+	// "#anchor" link may be resolved by a block uploaded later
+	known map[string]bool
+
+	targets map[string]string
+	pending []pendingLink
+	linked  int
+}
+
+// pendingLink is an uploaded block holding links to anchors not uploaded yet
+type pendingLink struct {
+	block notion.Block
+	// changed records whether some of its links were already resolved
+	changed bool
+}
+
+// newLinker returns a linker for blocks uploaded to a page. known holds the
+// anchors defined in the whole document, or is nil when they are not known
+// upfront.
+func (p *publisher) newLinker(pageID string, known map[string]bool) *linker {
+	return &linker{p: p, pageID: pageID, known: known, targets: make(map[string]string)}
+}
+
+// add records the anchors of uploaded blocks and updates the blocks whose
+// links can be resolved. created holds the top-level blocks returned by
+// Notion for blocks, in the same order.
+func (l *linker) add(ctx context.Context, blocks, created []notion.Block) error {
+	if l.known != nil && (len(l.known) == 0 || !anyBlock(blocks, l.hasLink)) {
+		return nil
+	}
+
+	// Only fetch IDs for subtrees that hold an anchor or a link to one
+	needsID := func(b *notion.Block) bool {
+		return b.Anchor != "" || l.hasLink(b)
+	}
+	if !anyBlock(blocks, needsID) {
+		return nil
+	}
+	if err := l.p.assignBlockIDs(ctx, blocks, created, needsID); err != nil {
+		return fmt.Errorf("failed to resolve uploaded block IDs: %w", err)
+	}
+
+	walkBlocks(blocks, func(b *notion.Block) {
+		if b.Anchor != "" && b.ID != "" {
+			l.targets[b.Anchor] = b.ID
+		}
+	})
+
+	var updates []notion.Block
+	walkBlocks(blocks, func(b *notion.Block) {
+		if b.ID == "" || !l.hasLink(b) {
+			return
+		}
+		changed, complete := l.resolve(b)
+		switch {
+		case !complete:
+			l.pending = append(l.pending, pendingLink{block: withoutChildren(*b), changed: changed})
+		case changed:
+			updates = append(updates, *b)
+		}
+	})
+	return l.update(ctx, updates)
+}
+
+// finish resolves the links left pending once all blocks are uploaded
+func (l *linker) finish(ctx context.Context) error {
+	var updates []notion.Block
+	for _, pending := range l.pending {
+		changed, _ := l.resolve(&pending.block)
+		if changed || pending.changed {
+			updates = append(updates, pending.block)
+		}
+	}
+	l.pending = nil
+	if err := l.update(ctx, updates); err != nil {
+		return err
+	}
+
+	if l.p.opts.Verbose && l.linked > 0 {
+		fmt.Fprintf(os.Stderr, "Linked %d blocks to %d anchors\n", l.linked, len(l.targets))
+	}
+	return nil
+}
+
+// update sends blocks whose links were resolved to Notion
+func (l *linker) update(ctx context.Context, blocks []notion.Block) error {
+	for _, block := range blocks {
+		if err := l.p.client.UpdateBlock(ctx, block); err != nil {
+			return fmt.Errorf("failed to update links in block %s: %w", block.ID, err)
+		}
+		l.linked++
+	}
+	return nil
+}
+
+// hasLink reports whether the block's own rich text links to an anchor
+// that may be resolved
+func (l *linker) hasLink(b *notion.Block) bool {
+	found := false
+	b.EachRichText(func(rt *notion.RichText) {
+		if name, ok := anchorName(rt.Href); ok && (l.known == nil || l.known[name]) {
+			found = true
+		}
+	})
+	return found
+}
+
+// resolve points the links of a block at the anchors uploaded so far. It
+// reports whether a link changed and whether no link is left to resolve.
+func (l *linker) resolve(b *notion.Block) (changed, complete bool) {
+	complete = true
+	b.EachRichText(func(rt *notion.RichText) {
+		name, ok := anchorName(rt.Href)
+		if !ok || (l.known != nil && !l.known[name]) {
+			return
+		}
+		id, ok := l.targets[name]
+		if !ok {
+			complete = false
+			return
+		}
+		href := blockURL(l.pageID, id)
+		rt.Href = &href
+		changed = true
+	})
+	return changed, complete
+}
+
+// withoutChildren returns a copy of a block that does not hold on to its
+// nested blocks, which updates leave untouched
+func withoutChildren(b notion.Block) notion.Block {
+	b.Children = nil
+	switch {
+	case b.BulletedListItem != nil:
+		item := *b.BulletedListItem
+		item.Children = nil
+		b.BulletedListItem = &item
+	case b.NumberedListItem != nil:
+		item := *b.NumberedListItem
+		item.Children = nil
+		b.NumberedListItem = &item
+	case b.Paragraph != nil:
+		paragraph := *b.Paragraph
+		paragraph.Children = nil
+		b.Paragraph = &paragraph
+	case b.Toggle != nil:
+		toggle := *b.Toggle
+		toggle.Children = nil
+		b.Toggle = &toggle
+	case b.Callout != nil:
+		callout := *b.Callout
+		callout.Children = nil
+		b.Callout = &callout
+	case b.ToDo != nil:
+		item := *b.ToDo
+		item.Children = nil
+		b.ToDo = &item
+	}
+	return b
+}
+
+// assignBlockIDs copies the IDs of uploaded blocks onto the converted blocks,
+// listing the children of uploaded blocks whenever a nested block needs one
+func (p *publisher) assignBlockIDs(ctx context.Context, blocks, uploaded []notion.Block, needsID func(*notion.Block) bool) error {
+	for i := range blocks {
+		if i >= len(uploaded) {
+			break
+		}
+		block := &blocks[i]
+		block.ID = uploaded[i].ID
+
+		children := block.ChildBlocks()
+		if len(children) == 0 || !anyBlock(children, needsID) {
+			continue
+		}
+
+		uploadedChildren, err := p.client.ListBlockChildren(ctx, block.ID)
+		if err != nil {
+			return err
+		}
+		if err := p.assignBlockIDs(ctx, children, uploadedChildren, needsID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectAnchors returns the set of anchor names defined in blocks
+func collectAnchors(blocks []notion.Block) map[string]bool {
+	anchors := make(map[string]bool)
+	walkBlocks(blocks, func(b *notion.Block) {
+		if b.Anchor != "" {
+			anchors[b.Anchor] = true
+		}
+	})
+	return anchors
+}
+
+// anchorName extracts the anchor from an intra-page "#anchor" link
+func anchorName(href *string) (string, bool) {
+	if href == nil || !strings.HasPrefix(*href, "#") || len(*href) == 1 {
+		return "", false
+	}
+	return (*href)[1:], true
+}
+
+// blockURL returns the URL of a block within a Notion page
+func blockURL(pageID, blockID string) string {
+	return fmt.Sprintf("https://notion.so/%s#%s",
+		strings.ReplaceAll(pageID, "-", ""), strings.ReplaceAll(blockID, "-", ""))
+}
+
+// anyBlock reports whether pred holds for a block in the tree
+func anyBlock(blocks []notion.Block, pred func(*notion.Block) bool) bool {
+	found := false
+	walkBlocks(blocks, func(b *notion.Block) {
+		if !found && pred(b) {
+			found = true
+		}
+	})
+	return found
+}
+
+// walkBlocks calls fn for every block in the tree, parents before children
+func walkBlocks(blocks []notion.Block, fn func(*notion.Block)) {
+	for i := range blocks {
+		fn(&blocks[i])
+		walkBlocks(blocks[i].ChildBlocks(), fn)
+	}
+}
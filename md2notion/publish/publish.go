@@ -0,0 +1,275 @@
+// md2notion/publish/publish.go
+
+// Package publish writes converted Markdown to Notion pages: it creates
+// pages, appends to them or replaces their content, uploads local images and
+// links intra-page anchors once the blocks exist.
+package publish
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+)
+
+// Mode selects how blocks are written to Notion
+type Mode string
+
+const (
+	// ModeAppend appends the blocks to the end of an existing page
+	ModeAppend Mode = "append"
+	// ModeReplace deletes the content of an existing page before appending
+	ModeReplace Mode = "replace"
+	// ModeCreate creates a new page under a parent page
+	ModeCreate Mode = "create"
+)
+
+// Options describes where and how blocks are published
+type Options struct {
+	// Mode defaults to ModeAppend
+	Mode Mode
+	// PageID is the target page with ModeAppend and ModeReplace
+	PageID string
+	// ParentID and Title describe the new page with ModeCreate
+	ParentID string
+	Title    string
+	// Icon sets the page icon from an emoji or a shortcode such as ":rocket:"
+	Icon string
+	// TagsProperty names the multi-select property of a database page that
+	// Tags are set in. Tags are left out without it.
+	TagsProperty string
+	Tags         []string
+	// Verbose logs progress to stderr
+	Verbose bool
+}
+
+// Validate checks that the options name a target for their mode
+func (o Options) Validate() error {
+	switch o.mode() {
+	case ModeCreate:
+		if o.ParentID == "" {
+			return fmt.Errorf("a parent page ID is required to create a page")
+		}
+		if o.Title == "" {
+			return fmt.Errorf("a title is required to create a page")
+		}
+	case ModeAppend, ModeReplace:
+		if o.PageID == "" {
+			return fmt.Errorf("a page ID is required to %s content", o.mode())
+		}
+	default:
+		return fmt.Errorf("unknown publish mode %q", o.Mode)
+	}
+	if _, err := convert.EmojiIcon(o.Icon); err != nil {
+		return err
+	}
+	return nil
+}
+
+// mode returns the mode with the default applied
+func (o Options) mode() Mode {
+	if o.Mode == "" {
+		return ModeAppend
+	}
+	return o.Mode
+}
+
+// Result describes the published page
+type Result struct {
+	PageID string
+	URL    string
+	// Blocks is the number of top-level blocks written to the page
+	Blocks int
+}
+
+// Blocks publishes converted blocks. Local images attached to the blocks
+// are uploaded first, so a failed upload leaves the page untouched. The
+// blocks are updated with the IDs Notion assigned to them.
+func Blocks(ctx context.Context, client *notionapi.Client, blocks []notionapi.Block, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &publisher{client: client, opts: opts}
+	count, err := p.uploadPendingFiles(ctx, blocks)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Verbose && count > 0 {
+		fmt.Fprintf(os.Stderr, "Uploaded %d images\n", count)
+	}
+
+	pageID, url, err := p.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Adding %d blocks...\n", len(blocks))
+	}
+	if err := p.uploadBlocks(ctx, pageID, blocks); err != nil {
+		return nil, fmt.Errorf("failed to append blocks: %w", err)
+	}
+
+	return &Result{PageID: pageID, URL: url, Blocks: len(blocks)}, nil
+}
+
+// Markdown converts a Markdown document with converter and publishes it.
+// The conversion diagnostics are returned even when publishing fails.
+func Markdown(ctx context.Context, client *notionapi.Client, converter *convert.Converter, markdown []byte, opts Options) (*Result, []convert.Diagnostic, error) {
+	// Fail before converting, which may upload images to an image host
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	page, diagnostics, err := converter.ConvertPageContext(ctx, markdown)
+	if err != nil {
+		return nil, diagnostics, fmt.Errorf("failed to convert markdown: %w", err)
+	}
+
+	for _, tag := range page.Tags {
+		if !slices.Contains(opts.Tags, tag) {
+			opts.Tags = append(opts.Tags, tag)
+		}
+	}
+	result, err := Blocks(ctx, client, page.Blocks, opts)
+	return result, diagnostics, err
+}
+
+// publisher carries the state of a single publication
+type publisher struct {
+	client *notionapi.Client
+	opts   Options
+}
+
+// target prepares the page blocks are written to: it creates the page, or
+// sets the icon of the existing page and deletes its content when replacing
+// it. It returns the ID and URL of the page.
+func (p *publisher) target(ctx context.Context) (string, string, error) {
+	switch p.opts.mode() {
+	case ModeCreate:
+		if p.opts.Verbose {
+			fmt.Fprintf(os.Stderr, "Creating new page '%s' under parent %s\n", p.opts.Title, p.opts.ParentID)
+		}
+		// The icon was checked by Options.Validate
+		icon, _ := convert.EmojiIcon(p.opts.Icon)
+		page, err := p.client.CreatePage(ctx, p.opts.ParentID, p.opts.Title, icon, nil)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create page: %w", err)
+		}
+		if err := p.setPageTags(ctx, page.ID); err != nil {
+			return "", "", err
+		}
+		return page.ID, page.URL, nil
+
+	case ModeReplace:
+		if p.opts.Verbose {
+			fmt.Fprintf(os.Stderr, "Replacing content of page %s\n", p.opts.PageID)
+		}
+		if err := p.setPageIcon(ctx); err != nil {
+			return "", "", err
+		}
+		if err := p.setPageTags(ctx, p.opts.PageID); err != nil {
+			return "", "", err
+		}
+		if err := p.deleteContent(ctx); err != nil {
+			return "", "", err
+		}
+
+	default:
+		if p.opts.Verbose {
+			fmt.Fprintf(os.Stderr, "Appending to page %s\n", p.opts.PageID)
+		}
+		if err := p.setPageIcon(ctx); err != nil {
+			return "", "", err
+		}
+		if err := p.setPageTags(ctx, p.opts.PageID); err != nil {
+			return "", "", err
+		}
+	}
+	return p.opts.PageID, pageURL(p.opts.PageID), nil
+}
+
+// deleteContent deletes the existing blocks of the target page
+func (p *publisher) deleteContent(ctx context.Context) error {
+	pageID := p.opts.PageID
+
+	// Get existing children
+	existingBlocks, err := p.client.ListBlockChildren(ctx, pageID)
+	if err != nil {
+		return fmt.Errorf("failed to list existing blocks: %w", err)
+	}
+	if len(existingBlocks) == 0 {
+		return nil
+	}
+
+	// Delete all existing blocks first (simple sequential deletion)
+	if p.opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Deleting %d existing blocks...\n", len(existingBlocks))
+	}
+	for i, block := range existingBlocks {
+		if p.opts.Verbose && i%10 == 0 {
+			fmt.Fprintf(os.Stderr, "Deleted %d/%d blocks\n", i, len(existingBlocks))
+		}
+		if err := p.client.DeleteBlock(ctx, block.ID); err != nil {
+			if p.opts.Verbose {
+				fmt.Fprintf(os.Stderr, "Warning: failed to delete block %s: %v\n", block.ID, err)
+			}
+		}
+	}
+	if p.opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Finished deleting all %d existing blocks\n", len(existingBlocks))
+	}
+	return nil
+}
+
+// setPageIcon applies the configured icon to the existing target page
+func (p *publisher) setPageIcon(ctx context.Context) error {
+	icon, _ := convert.EmojiIcon(p.opts.Icon)
+	if icon == nil {
+		return nil
+	}
+	if err := p.client.SetPageIcon(ctx, p.opts.PageID, icon); err != nil {
+		return fmt.Errorf("failed to set page icon: %w", err)
+	}
+	return nil
+}
+
+// setPageTags sets the tags in the tags property of the target page
+func (p *publisher) setPageTags(ctx context.Context, pageID string) error {
+	if p.opts.TagsProperty == "" || len(p.opts.Tags) == 0 {
+		return nil
+	}
+	if p.opts.Verbose {
+		fmt.Fprintf(os.Stderr, "Setting %s to %s\n", p.opts.TagsProperty, strings.Join(p.opts.Tags, ", "))
+	}
+	if err := p.client.SetPageTags(ctx, pageID, p.opts.TagsProperty, p.opts.Tags); err != nil {
+		return fmt.Errorf("failed to set page tags: %w", err)
+	}
+	return nil
+}
+
+// uploadBlocks appends blocks to a page, then resolves links between them
+// that can only be pointed at their targets once the blocks have IDs
+func (p *publisher) uploadBlocks(ctx context.Context, pageID string, blocks []notion.Block) error {
+	created, err := p.client.AppendBlockChildren(ctx, pageID, blocks)
+	if err != nil {
+		return err
+	}
+
+	l := p.newLinker(pageID, collectAnchors(blocks))
+	if err := l.add(ctx, blocks, created); err != nil {
+		return err
+	}
+	return l.finish(ctx)
+}
+
+// pageURL returns the URL of an existing page
+func pageURL(pageID string) string {
+	return "https://notion.so/" + strings.ReplaceAll(pageID, "-", "")
+}
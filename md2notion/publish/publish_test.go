@@ -0,0 +1,240 @@
+package publish_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/publish"
+)
+
+// The exported API must keep these signatures; changing one is a breaking
+// change. Every assertion is a declaration of its own so that adding one never
+// touches the others: append new assertions and leave existing ones as they are.
+var _ func(context.Context, *notionapi.Client, []notionapi.Block, publish.Options) (*publish.Result, error) = publish.Blocks
+var _ func(context.Context, *notionapi.Client, *convert.Converter, []byte, publish.Options) (*publish.Result, []convert.Diagnostic, error) = publish.Markdown
+var _ func(publish.Options) error = publish.Options.Validate
+var _ func(context.Context, *notionapi.Client, <-chan notionapi.Block, publish.Options) (*publish.Result, error) = publish.Stream
+var _ func(context.Context, *notionapi.Client, *convert.Converter, io.Reader, publish.Options) (*publish.Result, []convert.Diagnostic, error) = publish.MarkdownStream
+
+// fakeNotion records API requests and answers them like Notion would
+type fakeNotion struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string]string
+	existing []string
+}
+
+func (f *fakeNotion) client() *notionapi.Client {
+	return notionapi.NewClient(notionapi.Options{
+		Token:      "secret",
+		HTTPClient: &http.Client{Transport: f},
+	})
+}
+
+func (f *fakeNotion) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v1")
+	call := req.Method + " " + path
+	f.requests = append(f.requests, call)
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	if f.bodies == nil {
+		f.bodies = make(map[string]string)
+	}
+	f.bodies[call] = string(body)
+
+	switch {
+	case call == "POST /pages":
+		return respond(`{"object":"page","id":"new-page","url":"https://www.notion.so/New-page"}`), nil
+	case req.Method == "PATCH" && strings.HasSuffix(path, "/children"):
+		var request notionapi.AppendBlockChildrenRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			return nil, err
+		}
+		var results []string
+		for i, block := range request.Children {
+			results = append(results, fmt.Sprintf(`{"object":"block","id":"block-%d","type":%q}`, i, block.Type))
+		}
+		return respond(`{"object":"list","results":[` + strings.Join(results, ",") + `]}`), nil
+	case req.Method == "GET" && strings.HasSuffix(path, "/children"):
+		var results []string
+		for _, id := range f.existing {
+			results = append(results, fmt.Sprintf(`{"object":"block","id":%q,"type":"paragraph"}`, id))
+		}
+		return respond(`{"object":"list","results":[` + strings.Join(results, ",") + `],"has_more":false}`), nil
+	default:
+		return respond(`{}`), nil
+	}
+}
+
+func respond(body string) *http.Response {
+	return &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    publish.Options
+		wantErr bool
+	}{
+		{"append", publish.Options{PageID: "page"}, false},
+		{"append without page", publish.Options{}, true},
+		{"replace without page", publish.Options{Mode: publish.ModeReplace}, true},
+		{"create", publish.Options{Mode: publish.ModeCreate, ParentID: "parent", Title: "Title"}, false},
+		{"create without title", publish.Options{Mode: publish.ModeCreate, ParentID: "parent"}, true},
+		{"unknown mode", publish.Options{Mode: "upsert", PageID: "page"}, true},
+		{"shortcode icon", publish.Options{PageID: "page", Icon: ":rocket:"}, false},
+		{"text icon", publish.Options{PageID: "page", Icon: "hello"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarkdownCreatesPage(t *testing.T) {
+	fake := &fakeNotion{}
+	result, diagnostics, err := publish.Markdown(context.Background(), fake.client(),
+		convert.New(convert.Options{}), []byte("# Report\n\nDone.\n"),
+		publish.Options{Mode: publish.ModeCreate, ParentID: "parent", Title: "Report", Icon: ":rocket:"})
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("got diagnostics %v, want none", diagnostics)
+	}
+	if result.PageID != "new-page" || result.URL != "https://www.notion.so/New-page" || result.Blocks != 2 {
+		t.Errorf("result = %+v, want the new page with 2 blocks", result)
+	}
+
+	want := []string{"POST /pages", "PATCH /blocks/new-page/children"}
+	if strings.Join(fake.requests, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requests = %v, want %v", fake.requests, want)
+	}
+	if !strings.Contains(fake.bodies["POST /pages"], `"emoji":"🚀"`) {
+		t.Errorf("page request %s has no rocket icon", fake.bodies["POST /pages"])
+	}
+}
+
+func TestMarkdownSetsPageTags(t *testing.T) {
+	fake := &fakeNotion{}
+	converter := convert.New(convert.Options{Dialect: convert.DialectObsidian})
+	_, _, err := publish.Markdown(context.Background(), fake.client(), converter, []byte("Filed under #ops and #team/infra.\n"),
+		publish.Options{Mode: publish.ModeCreate, ParentID: "parent", Title: "Runbook", TagsProperty: "Tags", Tags: []string{"ops"}})
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+
+	want := []string{"POST /pages", "PATCH /pages/new-page", "PATCH /blocks/new-page/children"}
+	if strings.Join(fake.requests, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requests = %v, want %v", fake.requests, want)
+	}
+	if body, want := fake.bodies["PATCH /pages/new-page"], `{"properties":{"Tags":{"multi_select":[{"name":"ops"},{"name":"team/infra"}]}}}`; body != want {
+		t.Errorf("tags request = %s, want %s", body, want)
+	}
+}
+
+func TestBlocksReplacesContent(t *testing.T) {
+	fake := &fakeNotion{existing: []string{"old-1", "old-2"}}
+	blocks, _, err := convert.Convert([]byte("New content.\n"), convert.Options{})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	result, err := publish.Blocks(context.Background(), fake.client(), blocks,
+		publish.Options{Mode: publish.ModeReplace, PageID: "page"})
+	if err != nil {
+		t.Fatalf("Blocks() error = %v", err)
+	}
+	if result.URL != "https://notion.so/page" {
+		t.Errorf("URL = %q, want the page URL", result.URL)
+	}
+
+	want := []string{
+		"GET /blocks/page/children",
+		"PATCH /blocks/old-1",
+		"PATCH /blocks/old-2",
+		"PATCH /blocks/page/children",
+	}
+	if strings.Join(fake.requests, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requests = %v, want %v", fake.requests, want)
+	}
+}
+
+func TestBlocksLinksAnchors(t *testing.T) {
+	fake := &fakeNotion{}
+	blocks, _, err := convert.Convert([]byte("# Intro\n\nSee [the intro](#intro).\n"), convert.Options{})
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if _, err := publish.Blocks(context.Background(), fake.client(), blocks, publish.Options{PageID: "page"}); err != nil {
+		t.Fatalf("Blocks() error = %v", err)
+	}
+
+	update, ok := fake.bodies["PATCH /blocks/block-1"]
+	if !ok {
+		t.Fatalf("requests = %v, want the paragraph updated", fake.requests)
+	}
+	if !strings.Contains(update, "https://notion.so/page#block0") {
+		t.Errorf("update %s does not link to the heading block", update)
+	}
+}
+
+func TestMarkdownStreamLinksLaterAnchors(t *testing.T) {
+	fake := &fakeNotion{}
+	result, _, err := publish.MarkdownStream(context.Background(), fake.client(),
+		convert.New(convert.Options{}), strings.NewReader("See [the outro](#outro).\n\n# Outro\n"),
+		publish.Options{PageID: "page"})
+	if err != nil {
+		t.Fatalf("MarkdownStream() error = %v", err)
+	}
+	if result.Blocks != 2 {
+		t.Errorf("Blocks = %d, want 2", result.Blocks)
+	}
+
+	want := []string{"PATCH /blocks/page/children", "PATCH /blocks/block-0"}
+	if strings.Join(fake.requests, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requests = %v, want %v", fake.requests, want)
+	}
+	if update := fake.bodies["PATCH /blocks/block-0"]; !strings.Contains(update, "https://notion.so/page#block1") {
+		t.Errorf("update %s does not link to the heading block", update)
+	}
+}
+
+func TestStreamDrainsAfterFailure(t *testing.T) {
+	blocks := make(chan notionapi.Block)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		defer close(blocks)
+		for i := 0; i < 10; i++ {
+			blocks <- notionapi.Block{Type: "divider", Divider: &notionapi.Divider{}}
+		}
+	}()
+
+	if _, err := publish.Stream(context.Background(), (&fakeNotion{}).client(), blocks, publish.Options{}); err == nil {
+		t.Fatal("Stream() without a target page succeeded")
+	}
+	<-sent
+}
@@ -0,0 +1,137 @@
+// md2notion/publish/stream.go
+package publish
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/convert"
+	"github.com/wiremind/markdown-to-notionapi/md2notion/notionapi"
+)
+
+// Stream publishes blocks received from a channel as they arrive: the target
+// page is prepared first, then the images of each block are uploaded and
+// the blocks appended in chunks, so only one chunk is held in memory.
+// Links to anchors are resolved as their targets are uploaded, and once the
+// channel is closed for links to anchors further down the page.
+//
+// Unlike Blocks, a failure leaves the blocks already appended on the page.
+// The channel is drained after a failure so its producer never blocks.
+func Stream(ctx context.Context, client *notionapi.Client, blocks <-chan notionapi.Block, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		go drain(blocks)
+		return nil, err
+	}
+
+	p := &publisher{client: client, opts: opts}
+	pageID, url, err := p.target(ctx)
+	if err != nil {
+		go drain(blocks)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Upload the images of each block before handing it to the client
+	ready := make(chan notion.Block)
+	uploaded := make(chan uploadResult, 1)
+	go func() {
+		defer close(ready)
+		var result uploadResult
+		for block := range blocks {
+			if result.err != nil {
+				continue
+			}
+			count, err := p.uploadPendingFiles(ctx, []notion.Block{block})
+			result.count += count
+			if err != nil {
+				result.err = err
+				continue
+			}
+			select {
+			case ready <- block:
+			case <-ctx.Done():
+				result.err = ctx.Err()
+			}
+		}
+		uploaded <- result
+	}()
+
+	l := p.newLinker(pageID, nil)
+	appended, err := client.AppendBlockStream(ctx, pageID, ready, func(chunk, created []notion.Block) error {
+		return l.add(ctx, chunk, created)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to append blocks: %w", err)
+	}
+
+	// The channel was closed, so the uploads are done
+	result := <-uploaded
+	if result.err != nil {
+		return nil, result.err
+	}
+	if opts.Verbose && result.count > 0 {
+		fmt.Fprintf(os.Stderr, "Uploaded %d images\n", result.count)
+	}
+
+	if err := l.finish(ctx); err != nil {
+		return nil, fmt.Errorf("failed to append blocks: %w", err)
+	}
+	return &Result{PageID: pageID, URL: url, Blocks: appended}, nil
+}
+
+// MarkdownStream converts Markdown read from r with converter and publishes
+// the blocks while the rest of the document is still being converted. The
+// conversion is cancelled when publishing fails. A conversion error is
+// returned with the result of publishing the blocks converted before it.
+func MarkdownStream(ctx context.Context, client *notionapi.Client, converter *convert.Converter, r io.Reader, opts Options) (*Result, []convert.Diagnostic, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blocks := make(chan notionapi.Block)
+	converted := make(chan conversionResult, 1)
+	go func() {
+		diagnostics, err := converter.ConvertStream(ctx, r, blocks)
+		converted <- conversionResult{diagnostics: diagnostics, err: err}
+	}()
+
+	result, err := Stream(ctx, client, blocks, opts)
+	if err != nil {
+		cancel()
+	}
+
+	conversion := <-converted
+	if err != nil {
+		return nil, conversion.diagnostics, err
+	}
+	if conversion.err != nil {
+		return result, conversion.diagnostics, fmt.Errorf("failed to convert markdown: %w", conversion.err)
+	}
+	return result, conversion.diagnostics, nil
+}
+
+// uploadResult is the outcome of uploading the images of streamed blocks
+type uploadResult struct {
+	count int
+	err   error
+}
+
+// conversionResult is the outcome of a streamed conversion
+type conversionResult struct {
+	diagnostics []convert.Diagnostic
+	err         error
+}
+
+// drain discards the blocks left in a channel until it is closed
+func drain(blocks <-chan notionapi.Block) {
+	for range blocks {
+	}
+}
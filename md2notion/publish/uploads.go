@@ -0,0 +1,36 @@
+// md2notion/publish/uploads.go
+package publish
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wiremind/markdown-to-notionapi/internal/notion"
+)
+
+// uploadPendingFiles uploads the local and embedded images of blocks to
+// Notion and points their image blocks at the resulting file uploads. It
+// returns the number of images uploaded.
+func (p *publisher) uploadPendingFiles(ctx context.Context, blocks []notion.Block) (int, error) {
+	var err error
+	count := 0
+	walkBlocks(blocks, func(b *notion.Block) {
+		if err != nil || b.Image == nil || b.Image.Pending == nil {
+			return
+		}
+
+		var id string
+		id, err = p.client.UploadFile(ctx, *b.Image.Pending)
+		if err != nil {
+			err = fmt.Errorf("failed to upload image %s: %w", b.Image.Pending.Name, err)
+			return
+		}
+		b.Image.FileUpload = &notion.FileUploadRef{ID: id}
+		b.Image.Pending = nil
+		count++
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}